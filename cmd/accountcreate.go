@@ -15,12 +15,16 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
 	e2wallet "github.com/wealdtech/go-eth2-wallet"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
@@ -32,6 +36,10 @@ var accountCreateCmd = &cobra.Command{
 
     ethdo account create --account="primary/operations" --passphrase="my secret"
 
+Multiple accounts can be created by including "{index}" in the account name (and path, if supplied), which is replaced by each index from --from for --count accounts.  Accounts that already exist are skipped, and a manifest of the accounts is output.  For example:
+
+    ethdo account create --account="Validators/{index}" --path="m/12381/3600/{index}/0/0" --from=0 --count=500 --walletpassphrase="my wallet secret" --passphrase="my secret"
+
 In quiet mode this will return 0 if the account is created successfully, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
@@ -42,6 +50,23 @@ In quiet mode this will return 0 if the account is created successfully, otherwi
 		wallet, err := walletFromInput(ctx)
 		errCheck(err, "Failed to access wallet")
 		outputIf(debug, fmt.Sprintf("Opened wallet %q of type %s", wallet.Name(), wallet.Type()))
+		kdfParams, err := kdfParamsFromInput()
+		errCheck(err, "Invalid key derivation parameters")
+		if !remote {
+			if kdfParams == nil {
+				kdfParams, err = walletKDFParams(wallet)
+				errCheck(err, "Failed to obtain key derivation parameters for wallet")
+			}
+			defaultParams, err := util.DefaultKDFParams("pbkdf2")
+			errCheck(err, "Failed to obtain default key derivation parameters")
+			if !kdfParams.Equal(defaultParams) {
+				wallet, err = walletWithKDFParams(wallet, kdfParams)
+				errCheck(err, "Failed to access wallet")
+				outputIf(debug, fmt.Sprintf("Encrypting keys with %s", kdfParams))
+			}
+		} else {
+			assert(kdfParams == nil, "key derivation parameters not available with remote wallets")
+		}
 		if wallet.Type() == "hierarchical deterministic" {
 			assert(getWalletPassphrase() != "", "walletpassphrase is required to create new accounts with hierarchical deterministic wallets")
 		}
@@ -50,39 +75,15 @@ In quiet mode this will return 0 if the account is created successfully, otherwi
 			errCheck(locker.Unlock(ctx, []byte(getWalletPassphrase())), "Failed to unlock wallet")
 		}
 
+		if strings.Contains(viper.GetString("account"), "{index}") {
+			accountCreateBulk(wallet)
+		}
+		assert(viper.GetUint32("count") == 1, "--account must contain {index} to create more than one account")
+
 		_, accountName, err := e2wallet.WalletAndAccountNames(viper.GetString("account"))
 		errCheck(err, "Failed to obtain account name")
 
-		var account e2wtypes.Account
-		if viper.GetUint("participants") > 0 {
-			// Want a distributed account.
-			distributedCreator, isDistributedCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
-			assert(isDistributedCreator, "Wallet does not support distributed account creation")
-			outputIf(debug, fmt.Sprintf("Distributed account has %d/%d threshold", viper.GetUint32("signing-threshold"), viper.GetUint32("participants")))
-			ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
-			defer cancel()
-			account, err = distributedCreator.CreateDistributedAccount(ctx, accountName, viper.GetUint32("participants"), viper.GetUint32("signing-threshold"), []byte(getOptionalPassphrase()))
-		} else {
-			if viper.GetString("path") != "" {
-				// Want a pathed account
-				creator, isCreator := wallet.(e2wtypes.WalletPathedAccountCreator)
-				assert(isCreator, "Wallet does not support account creation with an explicit path")
-				var match bool
-				match, err = regexp.Match("^m/[0-9]+/[0-9]+(/[0-9+])+", []byte(viper.GetString("path")))
-				errCheck(err, "Unable to match path to regular expression")
-				assert(match, "Path does not match expected format m/...")
-				ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
-				defer cancel()
-				account, err = creator.CreatePathedAccount(ctx, viper.GetString("path"), accountName, []byte(getPassphrase()))
-			} else {
-				// Want a standard account.
-				creator, isCreator := wallet.(e2wtypes.WalletAccountCreator)
-				assert(isCreator, "Wallet does not support account creation")
-				ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
-				defer cancel()
-				account, err = creator.CreateAccount(ctx, accountName, []byte(getPassphrase()))
-			}
-		}
+		account, err := accountCreate(wallet, accountName, viper.GetString("path"))
 		errCheck(err, "Failed to create account")
 
 		if pubKeyProvider, ok := account.(e2wtypes.AccountCompositePublicKeyProvider); ok {
@@ -95,12 +96,106 @@ In quiet mode this will return 0 if the account is created successfully, otherwi
 	},
 }
 
+// accountManifestEntry is an entry in the manifest of accounts created in bulk.
+type accountManifestEntry struct {
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	PublicKey string `json:"pubkey"`
+	UUID      string `json:"uuid"`
+}
+
+// accountCreateBulk creates a range of accounts, replacing {index} in the account name and path with each index
+// in turn.  Accounts that already exist are skipped.  A manifest of the accounts is output.
+func accountCreateBulk(wallet e2wtypes.Wallet) {
+	assert(viper.GetUint32("count") > 0, "--count must be at least 1")
+	assert(viper.GetString("path") == "" || strings.Contains(viper.GetString("path"), "{index}"), "--path must contain {index} when creating multiple accounts")
+	accountByNameProvider, isAccountByNameProvider := wallet.(e2wtypes.WalletAccountByNameProvider)
+	assert(isAccountByNameProvider, "Wallet cannot obtain accounts by name")
+
+	manifest := make([]*accountManifestEntry, 0, viper.GetUint32("count"))
+	for index := viper.GetUint32("from"); index < viper.GetUint32("from")+viper.GetUint32("count"); index++ {
+		_, accountName, err := e2wallet.WalletAndAccountNames(strings.ReplaceAll(viper.GetString("account"), "{index}", fmt.Sprintf("%d", index)))
+		errCheck(err, "Failed to obtain account name")
+		path := strings.ReplaceAll(viper.GetString("path"), "{index}", fmt.Sprintf("%d", index))
+
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		account, err := accountByNameProvider.AccountByName(ctx, accountName)
+		cancel()
+		if err == nil {
+			// Account already exists; ensure that it is the account we would have created.
+			if pathProvider, isPathProvider := account.(e2wtypes.AccountPathProvider); isPathProvider && path != "" {
+				assert(pathProvider.Path() == path, fmt.Sprintf("Account %q already exists with path %s", accountName, pathProvider.Path()))
+			}
+			outputIf(debug, fmt.Sprintf("Account %q already exists", accountName))
+		} else {
+			account, err = accountCreate(wallet, accountName, path)
+			errCheck(err, fmt.Sprintf("Failed to create account %q", accountName))
+			outputIf(debug, fmt.Sprintf("Created account %q", accountName))
+		}
+
+		entry := &accountManifestEntry{
+			Name: fmt.Sprintf("%s/%s", wallet.Name(), accountName),
+			UUID: account.ID().String(),
+		}
+		if pathProvider, isPathProvider := account.(e2wtypes.AccountPathProvider); isPathProvider {
+			entry.Path = pathProvider.Path()
+		}
+		pubKey, err := bestPublicKey(account)
+		errCheck(err, fmt.Sprintf("Failed to obtain public key for account %q", accountName))
+		entry.PublicKey = fmt.Sprintf("%#x", pubKey.Marshal())
+		manifest = append(manifest, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	errCheck(err, "Failed to generate manifest")
+	if viper.GetString("manifest") != "" {
+		errCheck(ioutil.WriteFile(viper.GetString("manifest"), data, 0600), "Failed to write manifest")
+	} else {
+		outputIf(!quiet, string(data))
+	}
+	os.Exit(_exitSuccess)
+}
+
+// accountCreate creates a single account in the wallet, which must already be unlocked if required.
+func accountCreate(wallet e2wtypes.Wallet, accountName string, path string) (e2wtypes.Account, error) {
+	if viper.GetUint("participants") > 0 {
+		// Want a distributed account.
+		distributedCreator, isDistributedCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
+		assert(isDistributedCreator, "Wallet does not support distributed account creation")
+		outputIf(debug, fmt.Sprintf("Distributed account has %d/%d threshold", viper.GetUint32("signing-threshold"), viper.GetUint32("participants")))
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+		return distributedCreator.CreateDistributedAccount(ctx, accountName, viper.GetUint32("participants"), viper.GetUint32("signing-threshold"), []byte(getOptionalPassphrase()))
+	}
+	if path != "" {
+		// Want a pathed account
+		creator, isCreator := wallet.(e2wtypes.WalletPathedAccountCreator)
+		assert(isCreator, "Wallet does not support account creation with an explicit path")
+		match, err := regexp.Match("^m/[0-9]+/[0-9]+(/[0-9]+)+$", []byte(path))
+		errCheck(err, "Unable to match path to regular expression")
+		assert(match, "Path does not match expected format m/...")
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+		return creator.CreatePathedAccount(ctx, path, accountName, []byte(getPassphrase()))
+	}
+	// Want a standard account.
+	creator, isCreator := wallet.(e2wtypes.WalletAccountCreator)
+	assert(isCreator, "Wallet does not support account creation")
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+	defer cancel()
+	return creator.CreateAccount(ctx, accountName, []byte(getPassphrase()))
+}
+
 func init() {
 	accountCmd.AddCommand(accountCreateCmd)
 	accountFlags(accountCreateCmd)
+	kdfFlags(accountCreateCmd)
 	accountCreateCmd.Flags().Uint32("participants", 0, "Number of participants (for distributed accounts)")
 	accountCreateCmd.Flags().Uint32("signing-threshold", 0, "Signing threshold (for distributed accounts)")
 	accountCreateCmd.Flags().String("path", "", "path of account (for hierarchical deterministic accounts)")
+	accountCreateCmd.Flags().Uint32("from", 0, "First index to create, if the account contains {index}")
+	accountCreateCmd.Flags().Uint32("count", 1, "Number of accounts to create, if the account contains {index}")
+	accountCreateCmd.Flags().String("manifest", "", "File to which to write the manifest of accounts, if the account contains {index} (default is to output it)")
 }
 
 func accountCreateBindings() {
@@ -113,4 +208,13 @@ func accountCreateBindings() {
 	if err := viper.BindPFlag("path", accountCreateCmd.Flags().Lookup("path")); err != nil {
 		panic(err)
 	}
+	if err := viper.BindPFlag("from", accountCreateCmd.Flags().Lookup("from")); err != nil {
+		panic(err)
+	}
+	if err := viper.BindPFlag("count", accountCreateCmd.Flags().Lookup("count")); err != nil {
+		panic(err)
+	}
+	if err := viper.BindPFlag("manifest", accountCreateCmd.Flags().Lookup("manifest")); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,164 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/grpc"
+	"github.com/wealdtech/ethdo/util"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"github.com/wealdtech/go-indexer"
+)
+
+var accountDeleteForce bool
+var accountDeleteKeystoreDir string
+var accountDeleteKeystorePassphrase string
+
+var accountDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete an account",
+	Long: `Delete an account from a non-deterministic or hierarchical deterministic wallet.  For example:
+
+    ethdo account delete --account="Personal wallet/Operations" --passphrase="my account passphrase" --keystore-dir=/backups --keystorepassphrase="my keystore secret"
+
+Deletion is refused if the account is known to the beacon chain as a validator, or if the state of the account cannot be obtained from the beacon node, unless --force is supplied.  If --keystore-dir is supplied the key is first exported to an EIP-2335 keystore in that directory.  The deletion is recorded in the activity log.
+
+In quiet mode this will return 0 if the account has been deleted, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(!remote, "account delete not available with remote wallets")
+		assert(viper.GetString("account") != "", "--account is required")
+		assert(accountDeleteKeystoreDir == "" || accountDeleteKeystorePassphrase != "", "--keystorepassphrase is required with --keystore-dir")
+
+		wallet, account, err := walletAndAccountFromInput(ctx)
+		errCheck(err, "Failed to obtain account")
+		assert(wallet.Type() == "non-deterministic" || wallet.Type() == "hierarchical deterministic", fmt.Sprintf("accounts cannot be deleted from wallets of type %q", wallet.Type()))
+		location, err := walletStoreLocation(wallet)
+		errCheck(err, "Failed to obtain store location for the wallet")
+
+		pubKey, err := bestPublicKey(account)
+		errCheck(err, "Failed to obtain public key")
+
+		if !accountDeleteForce {
+			err := connect()
+			errCheck(err, "Failed to obtain connection to Ethereum 2 beacon chain node; use --force to delete regardless")
+			validatorInfo, err := grpc.FetchValidatorInfo(eth2GRPCConn, account)
+			errCheck(err, "Failed to obtain validator information; use --force to delete regardless")
+			assert(!validatorKnown(validatorInfo.Status), fmt.Sprintf("Account is a validator with status %v; use --force to delete regardless", validatorInfo.Status))
+		}
+
+		details := map[string]string{
+			"account": fmt.Sprintf("%s/%s", wallet.Name(), account.Name()),
+			"uuid":    account.ID().String(),
+			"pubkey":  fmt.Sprintf("%#x", pubKey.Marshal()),
+		}
+
+		if accountDeleteKeystoreDir != "" {
+			filename, err := accountToKeystore(ctx, account, accountDeleteKeystoreDir, accountDeleteKeystorePassphrase)
+			errCheck(err, "Failed to export keystore")
+			outputIf(verbose, fmt.Sprintf("Keystore written to %s", filename))
+			details["keystore"] = filename
+		}
+
+		errCheck(removeAccount(location, wallet, account), "Failed to delete account")
+		errCheck(logActivity("account delete", details), "Failed to log deletion")
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+// accountToKeystore writes the key of an account to an EIP-2335 keystore in the given directory, returning its filename.
+func accountToKeystore(ctx context.Context, account e2wtypes.Account, dir string, passphrase string) (string, error) {
+	privateKey, err := accountPrivateKey(ctx, account)
+	if err != nil {
+		return "", err
+	}
+	path := ""
+	if pathProvider, ok := account.(e2wtypes.AccountPathProvider); ok {
+		path = pathProvider.Path()
+	}
+	keystore, err := util.NewKeystore(keystorev4.New(), privateKey.Marshal(), privateKey.PublicKey().Marshal(), path, passphrase)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(keystore)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate keystore JSON")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", errors.Wrap(err, "failed to create keystore directory")
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("keystore-%s.json", keystore.PublicKey))
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		return "", fmt.Errorf("keystore %s already exists", filename)
+	}
+	if err := ioutil.WriteFile(filename, data, 0600); err != nil {
+		return "", errors.Wrap(err, "failed to write keystore")
+	}
+	return filename, nil
+}
+
+// removeAccount removes an account from its wallet in a filesystem store, and from the wallet's index of accounts.
+func removeAccount(location string, wallet e2wtypes.Wallet, account e2wtypes.Account) error {
+	storeProvider, ok := wallet.(e2wtypes.StoreProvider)
+	if !ok {
+		return errors.New("cannot obtain store for the wallet")
+	}
+	walletStore := storeProvider.Store()
+	original, err := walletStore.RetrieveAccountsIndex(wallet.ID())
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain accounts index")
+	}
+	index, err := indexer.Deserialize(original)
+	if err != nil {
+		return errors.Wrap(err, "accounts index corrupt")
+	}
+	index.Remove(account.ID(), account.Name())
+	data, err := index.Serialize()
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize accounts index")
+	}
+	// Update the index before removing the account, so that a failure cannot leave the index referring to a
+	// missing account file.
+	if err := walletStore.StoreAccountsIndex(wallet.ID(), data); err != nil {
+		return errors.Wrap(err, "failed to store accounts index")
+	}
+	if err := os.Remove(filepath.Join(location, wallet.ID().String(), account.ID().String())); err != nil {
+		if restoreErr := walletStore.StoreAccountsIndex(wallet.ID(), original); restoreErr != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to remove account, and failed to restore accounts index (%v)", restoreErr))
+		}
+		return errors.Wrap(err, "failed to remove account")
+	}
+	return nil
+}
+
+func init() {
+	accountCmd.AddCommand(accountDeleteCmd)
+	accountFlags(accountDeleteCmd)
+	accountDeleteCmd.Flags().BoolVar(&accountDeleteForce, "force", false, "Delete the account even if it is a validator")
+	accountDeleteCmd.Flags().StringVar(&accountDeleteKeystoreDir, "keystore-dir", "", "Directory in which to write a keystore of the account before deleting it")
+	accountDeleteCmd.Flags().StringVar(&accountDeleteKeystorePassphrase, "keystorepassphrase", "", "Passphrase protecting the keystore")
+}
@@ -0,0 +1,157 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var accountDKGParticipants []string
+var accountDKGSigningThreshold uint32
+
+// accountDKGCmd represents the account dkg command
+var accountDKGCmd = &cobra.Command{
+	Use:   "dkg",
+	Short: "Create a distributed account with a local key generation ceremony",
+	Long: `Create a distributed account by simulating a distributed key generation ceremony locally, without the need for a running Dirk cluster.  For example:
+
+    ethdo account dkg --participant="Wallet 1/Validator 1@dirk1.example.com:13141" --participant="Wallet 2/Validator 1@dirk2.example.com:13141" --participant="Wallet 3/Validator 1@dirk3.example.com:13141" --signing-threshold=2 --passphrase="my account secret"
+
+Each participant is given as the account to create followed by "@" and the endpoint of the Dirk instance that will hold the account.  Participants are assigned IDs in the order in which they are supplied.  The wallets must already exist and be distributed wallets.  The composite private key is never created.  If the account for any participant cannot be created, the accounts already created for earlier participants are removed.
+
+In quiet mode this will return 0 if the accounts are created successfully, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(!remote, "account dkg not available with remote wallets")
+		assert(len(accountDKGParticipants) > 1, "at least two --participant values are required")
+		assert(accountDKGSigningThreshold != 0, "--signing-threshold is required")
+		participants := uint32(len(accountDKGParticipants))
+		assert(accountDKGSigningThreshold > participants/2, "--signing-threshold must be more than half the number of participants")
+		assert(accountDKGSigningThreshold <= participants, "--signing-threshold cannot be more than the number of participants")
+		passphrase := getPassphrase()
+
+		// Parse the participants, and ensure the accounts can be created before generating keys.
+		accountPaths := make(map[uint64]string, participants)
+		endpoints := make(map[uint64]string, participants)
+		wallets := make(map[uint64]e2wtypes.Wallet, participants)
+		importers := make(map[uint64]e2wtypes.WalletDistributedAccountImporter, participants)
+		for i, participant := range accountDKGParticipants {
+			id := uint64(i + 1)
+			sep := strings.LastIndex(participant, "@")
+			assert(sep > 0, fmt.Sprintf("Participant %q must be of the form wallet/account@host:port", participant))
+			accountPaths[id] = participant[:sep]
+			endpoints[id] = participant[sep+1:]
+			_, err := remotesToEndpoints([]string{endpoints[id]})
+			errCheck(err, fmt.Sprintf("Invalid endpoint for participant %d", id))
+			for j := uint64(1); j < id; j++ {
+				assert(endpoints[j] != endpoints[id], fmt.Sprintf("Endpoint %s supplied more than once", endpoints[id]))
+			}
+
+			wallet, err := walletFromPath(ctx, accountPaths[id])
+			errCheck(err, fmt.Sprintf("Failed to access wallet for participant %d", id))
+			importer, isImporter := wallet.(e2wtypes.WalletDistributedAccountImporter)
+			assert(isImporter, fmt.Sprintf("Wallet %q for participant %d is not a distributed wallet", wallet.Name(), id))
+			_, _, err = walletAndAccountFromPath(ctx, accountPaths[id])
+			assert(err != nil, fmt.Sprintf("Account %q already exists", accountPaths[id]))
+			if locker, isLocker := wallet.(e2wtypes.WalletLocker); isLocker {
+				errCheck(locker.Unlock(ctx, []byte(getWalletPassphrase())), fmt.Sprintf("Failed to unlock wallet for participant %d", id))
+			}
+			wallets[id] = wallet
+			importers[id] = importer
+		}
+
+		shares, verificationVector, err := util.DistributedKeyGeneration(accountDKGSigningThreshold, participants)
+		errCheck(err, "Failed to generate keys")
+		outputIf(debug, fmt.Sprintf("Generated %d/%d threshold keys", accountDKGSigningThreshold, participants))
+
+		created := make([]*accountDKGCreated, 0, participants)
+		for id := uint64(1); id <= uint64(participants); id++ {
+			account, err := accountDKGImport(ctx, importers[id], accountPaths[id], shares[id], verificationVector, endpoints, passphrase)
+			if account != nil {
+				created = append(created, &accountDKGCreated{wallet: wallets[id], account: account, path: accountPaths[id]})
+			}
+			if err != nil {
+				accountDKGRollback(created)
+				die(fmt.Sprintf("Failed to create account for participant %d: %v", id, err))
+			}
+			outputIf(debug, fmt.Sprintf("Created account %q for participant %d", accountPaths[id], id))
+		}
+		outputIf(verbose, fmt.Sprintf("%#x", verificationVector[0]))
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+// accountDKGCreated is an account created for a participant.
+type accountDKGCreated struct {
+	wallet  e2wtypes.Wallet
+	account e2wtypes.Account
+	path    string
+}
+
+// accountDKGImport creates the account for a participant, checking that it has the expected composite public key.
+func accountDKGImport(ctx context.Context, importer e2wtypes.WalletDistributedAccountImporter, path string, share []byte, verificationVector [][]byte, endpoints map[uint64]string, passphrase string) (e2wtypes.Account, error) {
+	_, accountName, err := e2wallet.WalletAndAccountNames(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain account name")
+	}
+	account, err := importer.ImportDistributedAccount(ctx, accountName, share, accountDKGSigningThreshold, verificationVector, endpoints, []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	distributedAccount, isDistributedAccount := account.(e2wtypes.DistributedAccount)
+	if !isDistributedAccount {
+		return account, errors.New("account is not a distributed account")
+	}
+	if !bytes.Equal(distributedAccount.CompositePublicKey().Marshal(), verificationVector[0]) {
+		return account, errors.New("account has an incorrect composite public key")
+	}
+	return account, nil
+}
+
+// accountDKGRollback removes the accounts created for earlier participants when the ceremony cannot be completed, so
+// that the shares of an unusable key are not left behind.
+func accountDKGRollback(created []*accountDKGCreated) {
+	for _, item := range created {
+		location, err := walletStoreLocation(item.wallet)
+		if err == nil {
+			err = removeAccount(location, item.wallet, item.account)
+		}
+		if err != nil {
+			outputIf(!quiet, fmt.Sprintf("Failed to remove account %q; it should be removed manually: %v", item.path, err))
+			continue
+		}
+		outputIf(verbose, fmt.Sprintf("Removed account %q", item.path))
+	}
+}
+
+func init() {
+	accountCmd.AddCommand(accountDKGCmd)
+	accountFlags(accountDKGCmd)
+	accountDKGCmd.Flags().StringArrayVar(&accountDKGParticipants, "participant", nil, "Participant as wallet/account@host:port (supply once for each participant)")
+	accountDKGCmd.Flags().Uint32Var(&accountDKGSigningThreshold, "signing-threshold", 0, "Signing threshold")
+}
@@ -0,0 +1,163 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/go-bytesutil"
+	e2util "github.com/wealdtech/go-eth2-util"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var accountFindPubKeys []string
+var accountFindPubKeysFile string
+var accountFindWithdrawalCredentials []string
+
+// accountFindTarget is a public key or withdrawal credentials for which to search.
+type accountFindTarget struct {
+	input                 string
+	pubKey                []byte
+	withdrawalCredentials []byte
+	found                 bool
+}
+
+var accountFindCmd = &cobra.Command{
+	Use:   "find",
+	Short: "Find accounts by public key",
+	Long: `Find the accounts that hold public keys or withdrawal credentials, searching all wallets in the store.  For example:
+
+    ethdo account find --pubkey=0xb384f767d964e100c8a9b21018d08c25ffebae268b3ab6d610353897541971726dbfc3c7463884c68a531515aab94c87
+
+--pubkey and --withdrawal-credentials can be supplied multiple times, and --pubkeys-file names a file containing one public key per line.  Both the public keys and composite public keys of accounts are matched.  Remote wallet daemons cannot list their wallets, so with --remote the wallets to search are given as a comma-separated list in --wallet.
+
+In quiet mode this will return 0 if an account is found for each key, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		targets, err := accountFindTargets()
+		errCheck(err, "Invalid search")
+		assert(len(targets) > 0, "--pubkey, --pubkeys-file or --withdrawal-credentials is required")
+
+		wallets, err := walletsToSearch(ctx)
+		errCheck(err, "Failed to obtain wallets")
+		assert(len(wallets) > 0, "No wallets to search")
+
+		for _, wallet := range wallets {
+			outputIf(debug, fmt.Sprintf("Searching wallet %s", wallet.Name()))
+			for account := range wallet.Accounts(ctx) {
+				pubKeys := make([][]byte, 0, 2)
+				if pubKeyProvider, ok := account.(e2wtypes.AccountPublicKeyProvider); ok {
+					pubKeys = append(pubKeys, pubKeyProvider.PublicKey().Marshal())
+				}
+				if pubKeyProvider, ok := account.(e2wtypes.AccountCompositePublicKeyProvider); ok {
+					pubKeys = append(pubKeys, pubKeyProvider.CompositePublicKey().Marshal())
+				}
+				for _, pubKey := range pubKeys {
+					withdrawalCredentials := e2util.SHA256(pubKey)
+					withdrawalCredentials[0] = byte(0) // BLS_WITHDRAWAL_PREFIX
+					for _, target := range targets {
+						if (target.pubKey != nil && bytes.Equal(target.pubKey, pubKey)) ||
+							(target.withdrawalCredentials != nil && bytes.Equal(target.withdrawalCredentials, withdrawalCredentials)) {
+							target.found = true
+							outputIf(!quiet, accountFindDescription(target, wallet, account))
+						}
+					}
+				}
+			}
+		}
+
+		for _, target := range targets {
+			if !target.found {
+				outputIf(verbose, fmt.Sprintf("%s: not found", target.input))
+			}
+		}
+		for _, target := range targets {
+			if !target.found {
+				os.Exit(_exitFailure)
+			}
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+// accountFindTargets obtains the public keys and withdrawal credentials for which to search from the input.
+func accountFindTargets() ([]*accountFindTarget, error) {
+	pubKeys := append([]string{}, accountFindPubKeys...)
+	if accountFindPubKeysFile != "" {
+		file, err := os.Open(accountFindPubKeysFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open public keys file")
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			pubKeys = append(pubKeys, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read public keys file")
+		}
+	}
+
+	targets := make([]*accountFindTarget, 0, len(pubKeys)+len(accountFindWithdrawalCredentials))
+	for _, input := range pubKeys {
+		pubKey, err := bytesutil.FromHexString(input)
+		if err != nil || len(pubKey) != 48 {
+			return nil, fmt.Errorf("invalid public key %s", input)
+		}
+		targets = append(targets, &accountFindTarget{input: input, pubKey: pubKey})
+	}
+	for _, input := range accountFindWithdrawalCredentials {
+		withdrawalCredentials, err := bytesutil.FromHexString(input)
+		if err != nil || len(withdrawalCredentials) != 32 {
+			return nil, fmt.Errorf("invalid withdrawal credentials %s", input)
+		}
+		targets = append(targets, &accountFindTarget{input: input, withdrawalCredentials: withdrawalCredentials})
+	}
+	return targets, nil
+}
+
+// accountFindDescription describes an account that matches a search target.
+func accountFindDescription(target *accountFindTarget, wallet e2wtypes.Wallet, account e2wtypes.Account) string {
+	description := fmt.Sprintf("%s: %s/%s", target.input, wallet.Name(), account.Name())
+	if pathProvider, ok := account.(e2wtypes.AccountPathProvider); ok && pathProvider.Path() != "" {
+		description = fmt.Sprintf("%s (path %s)", description, pathProvider.Path())
+	}
+	if verbose {
+		description = fmt.Sprintf("%s\n UUID: %v\n Wallet type: %s", description, account.ID(), wallet.Type())
+	}
+	return description
+}
+
+func init() {
+	accountCmd.AddCommand(accountFindCmd)
+	accountFlags(accountFindCmd)
+	walletFlags(accountFindCmd)
+	accountFindCmd.Flags().StringArrayVar(&accountFindPubKeys, "pubkey", nil, "A public key for which to search (supply once for each public key)")
+	accountFindCmd.Flags().StringVar(&accountFindPubKeysFile, "pubkeys-file", "", "A file containing public keys for which to search, one per line")
+	accountFindCmd.Flags().StringArrayVar(&accountFindWithdrawalCredentials, "withdrawal-credentials", nil, "Withdrawal credentials for which to search (supply once for each set of credentials)")
+}
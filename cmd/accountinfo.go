@@ -71,6 +71,11 @@ In quiet mode this will return 0 if the account exists, otherwise 1.`,
 			withdrawalCredentials := util.SHA256(withdrawalPubKey.Marshal())
 			withdrawalCredentials[0] = byte(0) // BLS_WITHDRAWAL_PREFIX
 			fmt.Printf("Withdrawal credentials: %#x\n", withdrawalCredentials)
+			if !remote {
+				kdfParams, err := accountKDFParams(wallet, account)
+				errCheck(err, "Failed to obtain key derivation parameters")
+				fmt.Printf("Key derivation: %s\n", kdfParams)
+			}
 		}
 		if pathProvider, ok := account.(e2wtypes.AccountPathProvider); ok {
 			if pathProvider.Path() != "" {
@@ -0,0 +1,93 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var accountLabelsSet []string
+var accountLabelsRemove []string
+
+var accountLabelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "Show or change the labels of accounts",
+	Long: `Show or change the labels of one or more accounts.  For example:
+
+    ethdo account labels --account="Validators/.*" --set=region=eu --set=operator=ops1
+
+Labels are key/value pairs that can be used to choose accounts with --select, for example --select="label:region=eu AND wallet:Validators".  The account name can be a regular expression, or accounts in any wallet can be chosen with --select.  Labels are held in labels.json in the base directory of a filesystem store, or in the file given by --labels-file.  If neither --set nor --remove is supplied the labels of the accounts are shown.
+
+In quiet mode this will return 0 if any accounts are found, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(viper.GetString("account") != "" || viper.GetString("select") != "", "--account or --select is required")
+
+		accounts, err := accountsFromInput(ctx, viper.GetString("account"))
+		errCheck(err, "Failed to obtain accounts")
+		labels, err := loadAccountLabels()
+		errCheck(err, "Failed to obtain labels")
+		if len(accounts) == 0 {
+			os.Exit(_exitFailure)
+		}
+
+		if len(accountLabelsSet) > 0 || len(accountLabelsRemove) > 0 {
+			for _, selected := range accounts {
+				for _, label := range accountLabelsSet {
+					kv := strings.SplitN(label, "=", 2)
+					assert(len(kv) == 2 && kv[0] != "", fmt.Sprintf("Label %q must be in the format key=value", label))
+					assert(!strings.ContainsAny(kv[0], " \t!=:()\""), fmt.Sprintf("Label key %q contains invalid characters", kv[0]))
+					labels.set(selected.wallet, selected.account, kv[0], kv[1])
+				}
+				for _, key := range accountLabelsRemove {
+					labels.remove(selected.account, key)
+				}
+				outputIf(verbose, fmt.Sprintf("Updated labels for account %s/%s", selected.wallet.Name(), selected.account.Name()))
+			}
+			errCheck(labels.save(), "Failed to save labels")
+			os.Exit(_exitSuccess)
+		}
+
+		for _, selected := range accounts {
+			outputIf(!quiet, fmt.Sprintf("%s/%s", selected.wallet.Name(), selected.account.Name()))
+			if verbose {
+				pubKey, err := bestPublicKey(selected.account)
+				if err == nil {
+					fmt.Printf(" Public key: %#x\n", pubKey.Marshal())
+				}
+			}
+			accountLabels := labels.labels(selected.account)
+			for _, key := range sortedLabelKeys(accountLabels) {
+				outputIf(!quiet, fmt.Sprintf(" %s: %s", key, accountLabels[key]))
+			}
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountLabelsCmd)
+	accountFlags(accountLabelsCmd)
+	selectFlags(accountLabelsCmd)
+	accountLabelsCmd.Flags().StringArrayVar(&accountLabelsSet, "set", nil, "A label to set, as key=value (supply once for each label)")
+	accountLabelsCmd.Flags().StringArrayVar(&accountLabelsRemove, "remove", nil, "The key of a label to remove (supply once for each label)")
+}
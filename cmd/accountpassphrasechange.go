@@ -0,0 +1,70 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var accountPassphraseChangeCmd = &cobra.Command{
+	Use:   "change",
+	Short: "Change the passphrase of accounts",
+	Long: `Change the passphrase of one or more accounts.  For example:
+
+    ethdo account passphrase change --account="Personal wallet/Operations" --passphrase="my old secret" --newpassphrase="my new secret"
+
+The account name can be a regular expression to change the passphrase of multiple accounts, for example --account="Validators/.*", or accounts in any wallet can be chosen with --select, for example --select="label:cohort=2020-q4".  If the accounts have different passphrases --passphrase can be supplied multiple times.  All accounts are checked before any are changed, and if any change fails those already made are rolled back.
+
+In quiet mode this will return 0 if the passphrases have been changed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(!remote, "account passphrase change not available with remote wallets")
+		assert(viper.GetString("account") != "" || viper.GetString("select") != "", "--account or --select is required")
+		assert(len(getPassphrases()) > 0, "--passphrase is required")
+		assert(getNewPassphrase() != "", "--newpassphrase is required")
+
+		accounts, err := accountsFromInput(ctx, viper.GetString("account"))
+		errCheck(err, "Failed to obtain accounts")
+		assert(len(accounts) > 0, "No accounts found")
+
+		// Re-encrypt all accounts before storing any, so that an incorrect passphrase does not leave a partial change.
+		updates := make([]*accountRecordUpdate, len(accounts))
+		for i, selected := range accounts {
+			name := fmt.Sprintf("%s/%s", selected.wallet.Name(), selected.account.Name())
+			assert(selected.wallet.Type() == "non-deterministic" || selected.wallet.Type() == "hierarchical deterministic", fmt.Sprintf("account passphrases cannot be changed for wallets of type %q", selected.wallet.Type()))
+			updates[i], err = newAccountRecordUpdate(selected.wallet, selected.account)
+			errCheck(err, fmt.Sprintf("Failed to retrieve account %s", name))
+			updates[i].update, err = reencryptRecord(updates[i].original, getPassphrases(), getNewPassphrase(), nil)
+			errCheck(err, fmt.Sprintf("Failed to change passphrase for account %s", name))
+		}
+
+		storeAccountRecords(updates, "Changed passphrase for")
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	accountPassphraseCmd.AddCommand(accountPassphraseChangeCmd)
+	accountFlags(accountPassphraseChangeCmd)
+	selectFlags(accountPassphraseChangeCmd)
+	newPassphraseFlags(accountPassphraseChangeCmd)
+}
@@ -0,0 +1,138 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var accountRecombineShares []string
+var accountRecombineSharePassphrase string
+
+// accountRecombineCmd represents the account recombine command
+var accountRecombineCmd = &cobra.Command{
+	Use:   "recombine",
+	Short: "Recombine threshold shares of a private key",
+	Long: `Recombine shares created by "ethdo account split" to recreate the original private key.  For example:
+
+    ethdo account recombine --share=shares/share-8e2f9e8c-1.json --share=shares/share-8e2f9e8c-3.json --share=shares/share-8e2f9e8c-4.json --sharepassphrase="my share passphrase" --account="Recovered/Operations" --passphrase="my account passphrase"
+
+The recombined key is verified against the original public key.  If --account is supplied the key is imported as a new account, otherwise the private key is output.
+
+In quiet mode this will return 0 if the key is recombined, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(len(accountRecombineShares) > 0, "--share is required")
+		assert(accountRecombineSharePassphrase != "", "--sharepassphrase is required")
+
+		encryptor := keystorev4.New()
+		var threshold uint32
+		var compositePubKey []byte
+		var verificationVector [][]byte
+		shares := make(map[uint64][]byte)
+		for _, shareFile := range accountRecombineShares {
+			data, err := ioutil.ReadFile(shareFile)
+			errCheck(err, fmt.Sprintf("Failed to read share %s", shareFile))
+			share := &accountShare{}
+			errCheck(json.Unmarshal(data, share), fmt.Sprintf("Invalid share %s", shareFile))
+			assert(share.Keystore != nil && share.ShareID != 0, fmt.Sprintf("Share %s is not a share", shareFile))
+
+			shareCompositePubKey, err := hex.DecodeString(strings.TrimPrefix(share.CompositePublicKey, "0x"))
+			errCheck(err, fmt.Sprintf("Invalid composite public key in share %s", shareFile))
+			if compositePubKey == nil {
+				compositePubKey = shareCompositePubKey
+				threshold = share.SigningThreshold
+				assert(threshold >= 1, fmt.Sprintf("Share %s has an invalid threshold", shareFile))
+				assert(len(share.VerificationVector) > 0, fmt.Sprintf("Share %s has no verification vector", shareFile))
+				verificationVector = make([][]byte, len(share.VerificationVector))
+				for i := range share.VerificationVector {
+					verificationVector[i], err = hex.DecodeString(strings.TrimPrefix(share.VerificationVector[i], "0x"))
+					errCheck(err, fmt.Sprintf("Invalid verification vector in share %s", shareFile))
+				}
+				assert(len(verificationVector) == int(threshold), "Verification vector does not match threshold")
+				assert(bytes.Equal(verificationVector[0], compositePubKey), "Verification vector does not match composite public key")
+			}
+			assert(bytes.Equal(shareCompositePubKey, compositePubKey), fmt.Sprintf("Share %s is for a different key", shareFile))
+			assert(share.SigningThreshold == threshold, fmt.Sprintf("Share %s has a different threshold", shareFile))
+			_, exists := shares[share.ShareID]
+			assert(!exists, fmt.Sprintf("Share %d supplied more than once", share.ShareID))
+
+			key, err := share.Decrypt(encryptor, accountRecombineSharePassphrase)
+			errCheck(err, fmt.Sprintf("Failed to decrypt share %s", shareFile))
+			sharePrivKey, err := e2types.BLSPrivateKeyFromBytes(key)
+			errCheck(err, fmt.Sprintf("Invalid private key in share %s", shareFile))
+			expectedPubKey, err := util.ThresholdSharePublicKey(verificationVector, share.ShareID)
+			errCheck(err, "Failed to calculate share public key")
+			assert(bytes.Equal(sharePrivKey.PublicKey().Marshal(), expectedPubKey), fmt.Sprintf("Share %s does not match its verification vector", shareFile))
+			shares[share.ShareID] = key
+			outputIf(debug, fmt.Sprintf("Share %d verified", share.ShareID))
+		}
+		assert(len(shares) >= int(threshold), fmt.Sprintf("At least %d shares are required to recombine the key", threshold))
+
+		key, err := util.RecoverThresholdKey(shares)
+		errCheck(err, "Failed to recombine key")
+		privateKey, err := e2types.BLSPrivateKeyFromBytes(key)
+		errCheck(err, "Recombined key is invalid")
+		assert(bytes.Equal(privateKey.PublicKey().Marshal(), compositePubKey), "Recombined key does not match the original public key")
+		outputIf(verbose, fmt.Sprintf("Recombined key for %#x", compositePubKey))
+
+		if viper.GetString("account") == "" {
+			outputIf(!quiet, fmt.Sprintf("%#x", privateKey.Marshal()))
+			os.Exit(_exitSuccess)
+		}
+
+		// Import the key in to the given account.
+		assert(!remote, "account recombine not available with remote wallets")
+		passphrase := getPassphrase()
+		wallet, err := walletFromPath(ctx, viper.GetString("account"))
+		errCheck(err, "Failed to access wallet")
+		importer, isImporter := wallet.(e2wtypes.WalletAccountImporter)
+		assert(isImporter, fmt.Sprintf("wallets of type %q do not allow importing accounts", wallet.Type()))
+		_, _, err = walletAndAccountFromPath(ctx, viper.GetString("account"))
+		assert(err != nil, "Account already exists")
+		if locker, isLocker := wallet.(e2wtypes.WalletLocker); isLocker {
+			errCheck(locker.Unlock(ctx, []byte(getWalletPassphrase())), "Failed to unlock wallet")
+		}
+		_, accountName, err := e2wallet.WalletAndAccountNames(viper.GetString("account"))
+		errCheck(err, "Failed to obtain account name")
+		_, err = importer.ImportAccount(ctx, accountName, privateKey.Marshal(), []byte(passphrase))
+		errCheck(err, "Failed to import account")
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountRecombineCmd)
+	accountFlags(accountRecombineCmd)
+	accountRecombineCmd.Flags().StringArrayVar(&accountRecombineShares, "share", nil, "A share file to recombine (supply once for each share)")
+	accountRecombineCmd.Flags().StringVar(&accountRecombineSharePassphrase, "sharepassphrase", "", "Passphrase with which the shares are encrypted")
+}
@@ -0,0 +1,117 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+var accountSplitThreshold uint32
+var accountSplitParticipants uint32
+var accountSplitSharePassphrase string
+var accountSplitDir string
+
+// accountSplitCmd represents the account split command
+var accountSplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split the private key of an account in to threshold shares",
+	Long: `Split the private key of an account in to shares, any threshold of which can recombine to form the original key.  For example:
+
+    ethdo account split --account="Personal wallet/Operations" --passphrase="my account passphrase" --threshold=3 --participants=5 --sharepassphrase="my share passphrase" --dir=shares
+
+Each share is written to its own encrypted keystore, along with the information required to verify and recombine it.
+
+In quiet mode this will return 0 if the shares are created, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(!remote, "account split not available with remote wallets")
+		assert(viper.GetString("account") != "", "--account is required")
+		assert(accountSplitParticipants > 1, "--participants must be at least 2")
+		assert(accountSplitThreshold > 0, "--threshold is required")
+		assert(accountSplitThreshold <= accountSplitParticipants, "--threshold cannot be more than --participants")
+		assert(accountSplitSharePassphrase != "", "--sharepassphrase is required")
+		assert(accountSplitDir != "", "--dir is required")
+
+		_, account, err := walletAndAccountFromInput(ctx)
+		errCheck(err, "Failed to obtain account")
+		privateKey, err := accountPrivateKey(ctx, account)
+		errCheck(err, "Failed to obtain private key")
+		pubKey := privateKey.PublicKey().Marshal()
+
+		shares, verificationVector, err := util.ThresholdShares(privateKey.Marshal(), accountSplitThreshold, accountSplitParticipants)
+		errCheck(err, "Failed to split private key")
+
+		verificationVectorHex := make([]string, len(verificationVector))
+		for i := range verificationVector {
+			verificationVectorHex[i] = hex.EncodeToString(verificationVector[i])
+		}
+
+		errCheck(os.MkdirAll(accountSplitDir, 0700), "Failed to create share directory")
+		encryptor := keystorev4.New()
+		for id := uint64(1); id <= uint64(accountSplitParticipants); id++ {
+			sharePubKey, err := util.ThresholdSharePublicKey(verificationVector, id)
+			errCheck(err, "Failed to obtain share public key")
+			keystore, err := util.NewKeystore(encryptor, shares[id], sharePubKey, "", accountSplitSharePassphrase)
+			errCheck(err, "Failed to create share keystore")
+			keystore.Description = fmt.Sprintf("Share %d of %d (threshold %d) for %#x", id, accountSplitParticipants, accountSplitThreshold, pubKey)
+			share := &accountShare{
+				Keystore:           keystore,
+				ShareID:            id,
+				SigningThreshold:   accountSplitThreshold,
+				Participants:       accountSplitParticipants,
+				CompositePublicKey: hex.EncodeToString(pubKey),
+				VerificationVector: verificationVectorHex,
+			}
+			data, err := json.Marshal(share)
+			errCheck(err, "Failed to generate share JSON")
+			filename := filepath.Join(accountSplitDir, fmt.Sprintf("share-%x-%d.json", pubKey[:4], id))
+			errCheck(ioutil.WriteFile(filename, data, 0600), "Failed to write share")
+			outputIf(verbose, fmt.Sprintf("Share %d: %s (public key %#x)", id, filename, sharePubKey))
+		}
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+// accountShare is a keystore holding a share of a private key, along with the information required to verify and recombine it.
+type accountShare struct {
+	*util.Keystore
+	ShareID            uint64   `json:"share_id"`
+	SigningThreshold   uint32   `json:"signing_threshold"`
+	Participants       uint32   `json:"participants"`
+	CompositePublicKey string   `json:"composite_pubkey"`
+	VerificationVector []string `json:"verification_vector"`
+}
+
+func init() {
+	accountCmd.AddCommand(accountSplitCmd)
+	accountFlags(accountSplitCmd)
+	accountSplitCmd.Flags().Uint32Var(&accountSplitThreshold, "threshold", 0, "Number of shares required to recombine the key")
+	accountSplitCmd.Flags().Uint32Var(&accountSplitParticipants, "participants", 0, "Number of shares to create")
+	accountSplitCmd.Flags().StringVar(&accountSplitSharePassphrase, "sharepassphrase", "", "Passphrase with which to encrypt the shares")
+	accountSplitCmd.Flags().StringVar(&accountSplitDir, "dir", "", "Directory in which to write the shares")
+}
@@ -0,0 +1,85 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var accountUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the key derivation parameters of accounts",
+	Long: `Re-encrypt one or more accounts with new key derivation parameters.  For example:
+
+    ethdo account upgrade --account="Validators/.*" --passphrase="my secret" --kdf=scrypt --kdf-n=1048576
+
+The account name can be a regular expression to upgrade multiple accounts, or accounts in any wallet can be chosen with --select.  If the accounts have different passphrases --passphrase can be supplied multiple times; each account keeps its existing passphrase.  Accounts that already use the parameters are left unchanged.  All accounts are checked before any are changed, and if any change fails those already made are rolled back.
+
+In quiet mode this will return 0 if the accounts have been upgraded, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(!remote, "account upgrade not available with remote wallets")
+		assert(viper.GetString("account") != "" || viper.GetString("select") != "", "--account or --select is required")
+		assert(len(getPassphrases()) > 0, "--passphrase is required")
+		assert(viper.GetString("kdf") != "", "--kdf is required")
+		kdfParams, err := kdfParamsFromInput()
+		errCheck(err, "Invalid key derivation parameters")
+
+		accounts, err := accountsFromInput(ctx, viper.GetString("account"))
+		errCheck(err, "Failed to obtain accounts")
+		assert(len(accounts) > 0, "No accounts found")
+
+		// Re-encrypt all accounts before storing any, so that an incorrect passphrase does not leave a partial change.
+		updates := make([]*accountRecordUpdate, 0, len(accounts))
+		for _, selected := range accounts {
+			name := fmt.Sprintf("%s/%s", selected.wallet.Name(), selected.account.Name())
+			assert(selected.wallet.Type() == "non-deterministic" || selected.wallet.Type() == "hierarchical deterministic", fmt.Sprintf("accounts cannot be upgraded for wallets of type %q", selected.wallet.Type()))
+			currentParams, err := accountKDFParams(selected.wallet, selected.account)
+			errCheck(err, fmt.Sprintf("Failed to obtain key derivation parameters for account %s", name))
+			if currentParams.Equal(kdfParams) {
+				outputIf(verbose, fmt.Sprintf("Account %s already uses %s", name, kdfParams))
+				continue
+			}
+			update, err := newAccountRecordUpdate(selected.wallet, selected.account)
+			errCheck(err, fmt.Sprintf("Failed to retrieve account %s", name))
+			// Decrypt with whichever passphrase matches, and re-encrypt with the same one.
+			for _, passphrase := range getPassphrases() {
+				update.update, err = reencryptRecord(update.original, []string{passphrase}, passphrase, kdfParams)
+				if err == nil {
+					break
+				}
+			}
+			errCheck(err, fmt.Sprintf("Failed to upgrade account %s", name))
+			updates = append(updates, update)
+		}
+
+		storeAccountRecords(updates, "Upgraded")
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountUpgradeCmd)
+	accountFlags(accountUpgradeCmd)
+	selectFlags(accountUpgradeCmd)
+	kdfFlags(accountUpgradeCmd)
+}
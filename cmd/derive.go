@@ -0,0 +1,114 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethdo/util"
+	e2util "github.com/wealdtech/go-eth2-util"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+var deriveMnemonic string
+var derivePath string
+var deriveFrom uint32
+var deriveCount uint32
+var deriveShowPrivateKey bool
+var deriveKeystoreDir string
+
+// deriveCmd represents the derive command
+var deriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive keys from a mnemonic",
+	Long: `Derive keys from a mnemonic and path, without creating a wallet.  For example:
+
+    ethdo derive --mnemonic="abandon abandon abandon … art" --path=m/12381/3600/0/0/0
+
+A range of keys can be derived by including "{index}" in the path, which is replaced by each index from --from for --count keys.  For example:
+
+    ethdo derive --mnemonic="abandon abandon abandon … art" --path=m/12381/3600/{index}/0/0 --from=0 --count=10
+
+Private keys are output with --show-private-key, and EIP-2335 keystores encrypted with --passphrase are written to the directory supplied with --keystore-dir.
+
+In quiet mode this will return 0 if the keys are derived, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(deriveMnemonic != "", "--mnemonic is required")
+		assert(derivePath != "", "--path is required")
+		assert(deriveCount > 0, "--count must be at least 1")
+		assert(uint64(deriveFrom)+uint64(deriveCount)-1 <= math.MaxUint32, "--from and --count must not go past the maximum index")
+		assert(deriveCount == 1 || strings.Contains(derivePath, "{index}"), "--path must contain {index} to derive more than one key")
+		passphrase := ""
+		if deriveKeystoreDir != "" {
+			passphrase = getPassphrase()
+			errCheck(os.MkdirAll(deriveKeystoreDir, 0700), "Failed to create keystore directory")
+		}
+
+		seed, err := util.SeedFromMnemonic(deriveMnemonic)
+		errCheck(err, "Failed to obtain seed from mnemonic")
+
+		encryptor := keystorev4.New()
+		for i := uint32(0); i < deriveCount; i++ {
+			index := deriveFrom + i
+			path := strings.ReplaceAll(derivePath, "{index}", fmt.Sprintf("%d", index))
+			privateKey, err := e2util.PrivateKeyFromSeedAndPath(seed, path)
+			errCheck(err, fmt.Sprintf("Failed to derive key for path %s", path))
+			pubKey := privateKey.PublicKey().Marshal()
+			withdrawalCredentials := e2util.SHA256(pubKey)
+			withdrawalCredentials[0] = byte(0) // BLS_WITHDRAWAL_PREFIX
+
+			if deriveKeystoreDir != "" {
+				keystore, err := util.NewKeystore(encryptor, privateKey.Marshal(), pubKey, path, passphrase)
+				errCheck(err, "Failed to create keystore")
+				data, err := json.Marshal(keystore)
+				errCheck(err, "Failed to generate keystore JSON")
+				filename := filepath.Join(deriveKeystoreDir, fmt.Sprintf("keystore-%s.json", strings.ReplaceAll(path, "/", "_")))
+				errCheck(ioutil.WriteFile(filename, data, 0600), "Failed to write keystore")
+				outputIf(debug, fmt.Sprintf("Wrote keystore %s", filename))
+			}
+
+			if quiet {
+				continue
+			}
+			if index != deriveFrom {
+				fmt.Println()
+			}
+			fmt.Printf("Path: %s\n", path)
+			fmt.Printf("Public key: %#x\n", pubKey)
+			fmt.Printf("Withdrawal credentials: %#x\n", withdrawalCredentials)
+			if deriveShowPrivateKey {
+				fmt.Printf("Private key: %#x\n", privateKey.Marshal())
+			}
+		}
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(deriveCmd)
+	deriveCmd.Flags().StringVar(&deriveMnemonic, "mnemonic", "", "The mnemonic from which to derive keys")
+	deriveCmd.Flags().StringVar(&derivePath, "path", "", "The path from which to derive keys, optionally containing {index}")
+	deriveCmd.Flags().Uint32Var(&deriveFrom, "from", 0, "The first index to derive")
+	deriveCmd.Flags().Uint32Var(&deriveCount, "count", 1, "The number of indices to derive")
+	deriveCmd.Flags().BoolVar(&deriveShowPrivateKey, "show-private-key", false, "Output the private keys")
+	deriveCmd.Flags().StringVar(&deriveKeystoreDir, "keystore-dir", "", "Directory in which to write EIP-2335 keystores for the keys")
+}
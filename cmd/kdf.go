@@ -0,0 +1,203 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var kdfFlagSet []*pflag.Flag
+
+// kdfFlags adds the flags that select key derivation parameters to a command.
+func kdfFlags(cmd *cobra.Command) {
+	if kdfFlagSet == nil {
+		cmd.Flags().String("kdf", "", "Key derivation function with which to encrypt keys (pbkdf2 or scrypt)")
+		cmd.Flags().Int("kdf-c", 0, "Number of iterations for pbkdf2 (default 262144)")
+		cmd.Flags().Int("kdf-n", 0, "CPU/memory cost for scrypt (default 262144)")
+		cmd.Flags().Int("kdf-r", 0, "Block size for scrypt (default 8)")
+		cmd.Flags().Int("kdf-p", 0, "Parallelisation for scrypt (default 1)")
+		for _, name := range []string{"kdf", "kdf-c", "kdf-n", "kdf-r", "kdf-p"} {
+			flag := cmd.Flags().Lookup(name)
+			if err := viper.BindPFlag(name, flag); err != nil {
+				panic(err)
+			}
+			kdfFlagSet = append(kdfFlagSet, flag)
+		}
+	} else {
+		for _, flag := range kdfFlagSet {
+			cmd.Flags().AddFlag(flag)
+		}
+	}
+}
+
+// kdfParamsFromInput obtains the key derivation parameters supplied by the user, or nil if none were supplied.
+func kdfParamsFromInput() (*util.KDFParams, error) {
+	if viper.GetString("kdf") == "" {
+		if viper.GetInt("kdf-c") != 0 || viper.GetInt("kdf-n") != 0 || viper.GetInt("kdf-r") != 0 || viper.GetInt("kdf-p") != 0 {
+			return nil, errors.New("--kdf is required with key derivation parameters")
+		}
+		return nil, nil
+	}
+	params, err := util.DefaultKDFParams(viper.GetString("kdf"))
+	if err != nil {
+		return nil, err
+	}
+	switch params.Function {
+	case "pbkdf2":
+		if viper.GetInt("kdf-n") != 0 || viper.GetInt("kdf-r") != 0 || viper.GetInt("kdf-p") != 0 {
+			return nil, errors.New("--kdf-n, --kdf-r and --kdf-p apply only to scrypt")
+		}
+		if viper.GetInt("kdf-c") != 0 {
+			params.C = viper.GetInt("kdf-c")
+		}
+	case "scrypt":
+		if viper.GetInt("kdf-c") != 0 {
+			return nil, errors.New("--kdf-c applies only to pbkdf2")
+		}
+		if viper.GetInt("kdf-n") != 0 {
+			params.N = viper.GetInt("kdf-n")
+		}
+		if viper.GetInt("kdf-r") != 0 {
+			params.R = viper.GetInt("kdf-r")
+		}
+		if viper.GetInt("kdf-p") != 0 {
+			params.P = viper.GetInt("kdf-p")
+		}
+	}
+	return params, params.Validate()
+}
+
+// walletKDFParams obtains the key derivation parameters with which a local wallet encrypts new keys.
+// These are recorded in the wallet for non-deterministic and distributed wallets, and are those of the seed for
+// hierarchical deterministic wallets.  Wallets without recorded parameters use the defaults.
+func walletKDFParams(wallet e2wtypes.Wallet) (*util.KDFParams, error) {
+	header, err := walletHeader(wallet)
+	if err != nil {
+		return nil, err
+	}
+	if kdf, exists := header["kdf"]; exists {
+		data, err := json.Marshal(kdf)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse wallet key derivation parameters")
+		}
+		params := &util.KDFParams{}
+		if err := json.Unmarshal(data, params); err != nil {
+			return nil, errors.Wrap(err, "failed to parse wallet key derivation parameters")
+		}
+		return params, params.Validate()
+	}
+	if crypto, ok := header["crypto"].(map[string]interface{}); ok {
+		return util.KDFParamsFromCrypto(crypto)
+	}
+	return util.DefaultKDFParams("pbkdf2")
+}
+
+// setWalletKDFParams records the key derivation parameters with which a non-deterministic or distributed wallet encrypts new keys.
+func setWalletKDFParams(wallet e2wtypes.Wallet, params *util.KDFParams) error {
+	header, err := walletHeader(wallet)
+	if err != nil {
+		return err
+	}
+	header["kdf"] = params
+	data, err := json.Marshal(header)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate wallet")
+	}
+	return wallet.(e2wtypes.StoreProvider).Store().StoreWallet(wallet.ID(), wallet.Name(), data)
+}
+
+// restoreWalletKDFParams records the key derivation parameters of an imported non-deterministic or distributed wallet.
+// Wallet exports do not carry the parameters, so they are taken from the wallet's accounts, which are encrypted with
+// them.  Wallets without accounts are left with the defaults.
+func restoreWalletKDFParams(ctx context.Context, wallet e2wtypes.Wallet) error {
+	if wallet.Type() != "non-deterministic" && wallet.Type() != "distributed" {
+		return nil
+	}
+	header, err := walletHeader(wallet)
+	if err != nil {
+		return err
+	}
+	if _, exists := header["kdf"]; exists {
+		return nil
+	}
+	for account := range wallet.Accounts(ctx) {
+		params, err := accountKDFParams(wallet, account)
+		if err != nil {
+			return err
+		}
+		return setWalletKDFParams(wallet, params)
+	}
+	return nil
+}
+
+// walletHeader obtains the stored wallet-level data of a local wallet.
+func walletHeader(wallet e2wtypes.Wallet) (map[string]interface{}, error) {
+	storeProvider, ok := wallet.(e2wtypes.StoreProvider)
+	if !ok {
+		return nil, errors.New("cannot obtain store for the wallet")
+	}
+	data, err := storeProvider.Store().RetrieveWalletByID(wallet.ID())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve wallet")
+	}
+	header := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Retain numbers as they are stored.
+	decoder.UseNumber()
+	if err := decoder.Decode(&header); err != nil {
+		return nil, errors.Wrap(err, "failed to parse wallet")
+	}
+	return header, nil
+}
+
+// accountKDFParams obtains the key derivation parameters with which a local account's key is encrypted.
+func accountKDFParams(wallet e2wtypes.Wallet, account e2wtypes.Account) (*util.KDFParams, error) {
+	storeProvider, ok := wallet.(e2wtypes.StoreProvider)
+	if !ok {
+		return nil, errors.New("cannot obtain store for the wallet")
+	}
+	data, err := storeProvider.Store().RetrieveAccount(wallet.ID(), account.ID())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve account")
+	}
+	record := &struct {
+		Crypto map[string]interface{} `json:"crypto"`
+	}{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, errors.Wrap(err, "failed to parse account")
+	}
+	if record.Crypto == nil {
+		return nil, errors.New("account has no crypto section")
+	}
+	return util.KDFParamsFromCrypto(record.Crypto)
+}
+
+// walletWithKDFParams reopens a local wallet so that new keys are encrypted with the given key derivation parameters.
+func walletWithKDFParams(wallet e2wtypes.Wallet, params *util.KDFParams) (e2wtypes.Wallet, error) {
+	encryptor, err := util.NewKeystoreEncryptor(params)
+	if err != nil {
+		return nil, err
+	}
+	return e2wallet.OpenWallet(wallet.Name(), e2wallet.WithEncryptor(encryptor))
+}
@@ -0,0 +1,109 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/keymanager"
+)
+
+var keymanagerCmd = &cobra.Command{
+	Use:   "keymanager",
+	Short: "Manage keys held by validator clients",
+	Long: `Manage the keys held by running validator clients through the keymanager API.
+
+The validator client is given by --keymanager-url, and its API token by --keymanager-token or --keymanager-token-file.`,
+}
+
+func init() {
+	RootCmd.AddCommand(keymanagerCmd)
+	keymanagerCmd.PersistentFlags().String("keymanager-url", "http://localhost:7500", "URL of the validator client's keymanager API")
+	if err := viper.BindPFlag("keymanager-url", keymanagerCmd.PersistentFlags().Lookup("keymanager-url")); err != nil {
+		panic(err)
+	}
+	keymanagerCmd.PersistentFlags().String("keymanager-token", "", "Bearer token for the keymanager API")
+	if err := viper.BindPFlag("keymanager-token", keymanagerCmd.PersistentFlags().Lookup("keymanager-token")); err != nil {
+		panic(err)
+	}
+	keymanagerCmd.PersistentFlags().String("keymanager-token-file", "", "File holding the bearer token for the keymanager API")
+	if err := viper.BindPFlag("keymanager-token-file", keymanagerCmd.PersistentFlags().Lookup("keymanager-token-file")); err != nil {
+		panic(err)
+	}
+}
+
+// keymanagerToken obtains the bearer token for the keymanager API.
+func keymanagerToken() (string, error) {
+	token := viper.GetString("keymanager-token")
+	if viper.GetString("keymanager-token-file") != "" {
+		if token != "" {
+			return "", errors.New("--keymanager-token and --keymanager-token-file cannot both be supplied")
+		}
+		data, err := ioutil.ReadFile(viper.GetString("keymanager-token-file"))
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read token file")
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return token, nil
+}
+
+// keymanagerClient creates a client for the keymanager API given by the user.
+func keymanagerClient() (*keymanager.Client, error) {
+	token, err := keymanagerToken()
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, errors.New("--keymanager-token or --keymanager-token-file is required")
+	}
+	return keymanager.NewClient(viper.GetString("keymanager-url"), token)
+}
+
+// keymanagerPubKeys obtains the validator public keys given by the user, either directly or as accounts.
+func keymanagerPubKeys(ctx context.Context, pubKeys []string) ([]string, error) {
+	res := make([]string, 0)
+	for _, pubKey := range pubKeys {
+		data, err := hex.DecodeString(strings.TrimPrefix(pubKey, "0x"))
+		if err != nil || len(data) != 48 {
+			return nil, fmt.Errorf("invalid public key %s", pubKey)
+		}
+		res = append(res, fmt.Sprintf("%#x", data))
+	}
+	if viper.GetString("select") == "" && viper.GetString("account") == "" {
+		return res, nil
+	}
+	if len(res) > 0 {
+		return nil, errors.New("keys can be given by --pubkey or as accounts, but not both")
+	}
+	accounts, err := accountsFromInput(ctx, viper.GetString("account"))
+	if err != nil {
+		return nil, err
+	}
+	for _, selected := range accounts {
+		pubKey, err := bestPublicKey(selected.account)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain public key for %s/%s", selected.wallet.Name(), selected.account.Name()))
+		}
+		res = append(res, fmt.Sprintf("%#x", pubKey.Marshal()))
+	}
+	return res, nil
+}
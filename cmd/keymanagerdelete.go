@@ -0,0 +1,88 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var keymanagerDeletePubKeys []string
+var keymanagerDeleteSlashingProtection string
+
+var keymanagerDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete keys from a validator client",
+	Long: `Delete keys from a validator client, storing the slashing protection data that it returns.  For example:
+
+    ethdo keymanager delete --pubkey=0xa99a...e44c --slashing-protection=slashing_protection.json --keymanager-token-file=api-token.txt
+
+Keys are given by --pubkey, which can be supplied multiple times, or as accounts with --account or --select.  The EIP-3076 slashing protection data returned by the validator client is written to the file given by --slashing-protection, which must not already exist.
+
+In quiet mode this will return 0 if all keys are deleted or inactive, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(keymanagerDeleteSlashingProtection != "", "--slashing-protection is required")
+		_, err := os.Stat(keymanagerDeleteSlashingProtection)
+		assert(os.IsNotExist(err), fmt.Sprintf("%s already exists", keymanagerDeleteSlashingProtection))
+		client, err := keymanagerClient()
+		errCheck(err, "Failed to set up keymanager client")
+		pubKeys, err := keymanagerPubKeys(ctx, keymanagerDeletePubKeys)
+		errCheck(err, "Failed to obtain keys")
+		assert(len(pubKeys) > 0, "--pubkey, --account or --select is required")
+
+		statuses, slashingProtection, err := client.DeleteKeystores(ctx, pubKeys)
+		// Store the slashing protection data before anything else, as the validator client may not return it again.
+		if slashingProtection != "" {
+			errCheck(ioutil.WriteFile(keymanagerDeleteSlashingProtection, []byte(slashingProtection), 0600), "Failed to write slashing protection file")
+			outputIf(verbose, fmt.Sprintf("Wrote %s", keymanagerDeleteSlashingProtection))
+		}
+		errCheck(err, "Failed to delete keys")
+
+		failed := false
+		for i, status := range statuses {
+			switch status.Status {
+			case "deleted":
+				outputIf(!quiet, fmt.Sprintf("Deleted %s", pubKeys[i]))
+			case "not_active":
+				outputIf(!quiet, fmt.Sprintf("Not active %s", pubKeys[i]))
+			default:
+				failed = true
+				outputIf(!quiet, fmt.Sprintf("Failed to delete %s: %s", pubKeys[i], statusDescription(status.Status, status.Message)))
+			}
+		}
+		if slashingProtection == "" {
+			outputIf(!quiet, "Validator client did not return slashing protection data")
+			failed = true
+		}
+		if failed {
+			os.Exit(_exitFailure)
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	keymanagerCmd.AddCommand(keymanagerDeleteCmd)
+	selectFlags(keymanagerDeleteCmd)
+	keymanagerDeleteCmd.Flags().StringArrayVar(&keymanagerDeletePubKeys, "pubkey", nil, "Public key to delete (can be supplied multiple times)")
+	keymanagerDeleteCmd.Flags().StringVar(&keymanagerDeleteSlashingProtection, "slashing-protection", "", "File in which to store the slashing protection data returned by the validator client")
+}
@@ -0,0 +1,94 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var keymanagerFeeRecipientPubKeys []string
+var keymanagerFeeRecipientSet string
+var keymanagerFeeRecipientRemove bool
+
+var keymanagerFeeRecipientCmd = &cobra.Command{
+	Use:   "fee-recipient",
+	Short: "Obtain or change the fee recipient of validators",
+	Long: `Obtain or change the fee recipient used by a validator client for validators.  For example:
+
+    ethdo keymanager fee-recipient --pubkey=0xa99a...e44c --set=0x1b5d...a61f --keymanager-token-file=api-token.txt
+
+Keys are given by --pubkey, which can be supplied multiple times, or as accounts with --account or --select.  With --set the fee recipient is changed, with --remove it is returned to the validator client's default, otherwise it is shown.
+
+In quiet mode this will return 0 if the operation succeeds for all keys, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(keymanagerFeeRecipientSet == "" || !keymanagerFeeRecipientRemove, "--set and --remove cannot both be supplied")
+		if keymanagerFeeRecipientSet != "" {
+			address, err := hex.DecodeString(strings.TrimPrefix(keymanagerFeeRecipientSet, "0x"))
+			assert(err == nil && len(address) == 20, "Invalid fee recipient address")
+		}
+		client, err := keymanagerClient()
+		errCheck(err, "Failed to set up keymanager client")
+		pubKeys, err := keymanagerPubKeys(ctx, keymanagerFeeRecipientPubKeys)
+		errCheck(err, "Failed to obtain keys")
+		assert(len(pubKeys) > 0, "--pubkey, --account or --select is required")
+
+		failed := false
+		for _, pubKey := range pubKeys {
+			switch {
+			case keymanagerFeeRecipientSet != "":
+				err = client.SetFeeRecipient(ctx, pubKey, keymanagerFeeRecipientSet)
+				if err == nil {
+					outputIf(verbose, fmt.Sprintf("Set fee recipient for %s", pubKey))
+				}
+			case keymanagerFeeRecipientRemove:
+				err = client.DeleteFeeRecipient(ctx, pubKey)
+				if err == nil {
+					outputIf(verbose, fmt.Sprintf("Removed fee recipient for %s", pubKey))
+				}
+			default:
+				var feeRecipient string
+				feeRecipient, err = client.FeeRecipient(ctx, pubKey)
+				if err == nil {
+					outputIf(!quiet, fmt.Sprintf("%s: %s", pubKey, feeRecipient))
+				}
+			}
+			if err != nil {
+				failed = true
+				outputIf(!quiet, fmt.Sprintf("%s: %v", pubKey, err))
+			}
+		}
+		if failed {
+			os.Exit(_exitFailure)
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	keymanagerCmd.AddCommand(keymanagerFeeRecipientCmd)
+	selectFlags(keymanagerFeeRecipientCmd)
+	keymanagerFeeRecipientCmd.Flags().StringArrayVar(&keymanagerFeeRecipientPubKeys, "pubkey", nil, "Public key of the validator (can be supplied multiple times)")
+	keymanagerFeeRecipientCmd.Flags().StringVar(&keymanagerFeeRecipientSet, "set", "", "Address to which to set the fee recipient")
+	keymanagerFeeRecipientCmd.Flags().BoolVar(&keymanagerFeeRecipientRemove, "remove", false, "Remove the fee recipient, returning it to the validator client's default")
+}
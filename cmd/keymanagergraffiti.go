@@ -0,0 +1,89 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var keymanagerGraffitiPubKeys []string
+var keymanagerGraffitiSet string
+var keymanagerGraffitiRemove bool
+
+var keymanagerGraffitiCmd = &cobra.Command{
+	Use:   "graffiti",
+	Short: "Obtain or change the graffiti of validators",
+	Long: `Obtain or change the graffiti used by a validator client for validators' blocks.  For example:
+
+    ethdo keymanager graffiti --pubkey=0xa99a...e44c --set="my graffiti" --keymanager-token-file=api-token.txt
+
+Keys are given by --pubkey, which can be supplied multiple times, or as accounts with --account or --select.  With --set the graffiti is changed, with --remove it is returned to the validator client's default, otherwise it is shown.  Graffiti can be at most 32 bytes.
+
+In quiet mode this will return 0 if the operation succeeds for all keys, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(keymanagerGraffitiSet == "" || !keymanagerGraffitiRemove, "--set and --remove cannot both be supplied")
+		assert(len(keymanagerGraffitiSet) <= 32, "Graffiti can be at most 32 bytes")
+		client, err := keymanagerClient()
+		errCheck(err, "Failed to set up keymanager client")
+		pubKeys, err := keymanagerPubKeys(ctx, keymanagerGraffitiPubKeys)
+		errCheck(err, "Failed to obtain keys")
+		assert(len(pubKeys) > 0, "--pubkey, --account or --select is required")
+
+		failed := false
+		for _, pubKey := range pubKeys {
+			switch {
+			case keymanagerGraffitiSet != "":
+				err = client.SetGraffiti(ctx, pubKey, keymanagerGraffitiSet)
+				if err == nil {
+					outputIf(verbose, fmt.Sprintf("Set graffiti for %s", pubKey))
+				}
+			case keymanagerGraffitiRemove:
+				err = client.DeleteGraffiti(ctx, pubKey)
+				if err == nil {
+					outputIf(verbose, fmt.Sprintf("Removed graffiti for %s", pubKey))
+				}
+			default:
+				var graffiti string
+				graffiti, err = client.Graffiti(ctx, pubKey)
+				if err == nil {
+					outputIf(!quiet, fmt.Sprintf("%s: %s", pubKey, graffiti))
+				}
+			}
+			if err != nil {
+				failed = true
+				outputIf(!quiet, fmt.Sprintf("%s: %v", pubKey, err))
+			}
+		}
+		if failed {
+			os.Exit(_exitFailure)
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	keymanagerCmd.AddCommand(keymanagerGraffitiCmd)
+	selectFlags(keymanagerGraffitiCmd)
+	keymanagerGraffitiCmd.Flags().StringArrayVar(&keymanagerGraffitiPubKeys, "pubkey", nil, "Public key of the validator (can be supplied multiple times)")
+	keymanagerGraffitiCmd.Flags().StringVar(&keymanagerGraffitiSet, "set", "", "Graffiti to set")
+	keymanagerGraffitiCmd.Flags().BoolVar(&keymanagerGraffitiRemove, "remove", false, "Remove the graffiti, returning it to the validator client's default")
+}
@@ -0,0 +1,143 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var keymanagerImportKeystorePassphrase string
+var keymanagerImportSlashingProtection string
+
+var keymanagerImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import keys in to a validator client",
+	Long: `Import the keys of accounts in to a validator client.  For example:
+
+    ethdo keymanager import --account=Validators/1 --passphrase="my account secret" --slashing-protection=slashing_protection.json --keymanager-token-file=api-token.txt
+
+All accounts in the wallet given by --wallet are imported, or those matching --account or --select.  Each key is sent as an EIP-2335 keystore encrypted with --keystorepassphrase, or if not supplied with a random passphrase.  If --slashing-protection names an EIP-3076 slashing protection file, the records for the imported keys are sent with them.
+
+In quiet mode this will return 0 if all keys are imported or already present, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(viper.GetString("remote") == "", "keymanager import not available with remote wallets")
+		client, err := keymanagerClient()
+		errCheck(err, "Failed to set up keymanager client")
+
+		var accounts []*selectedAccount
+		switch {
+		case viper.GetString("select") != "":
+			accounts, err = accountsFromInput(ctx, "")
+		case viper.GetString("account") != "":
+			accounts, err = accountsFromInput(ctx, viper.GetString("account"))
+		default:
+			assert(viper.GetString("wallet") != "", "--wallet, --account or --select is required")
+			accounts, err = accountsFromInput(ctx, viper.GetString("wallet"))
+		}
+		errCheck(err, "Failed to obtain accounts")
+		assert(len(accounts) > 0, "No accounts to import")
+
+		encryptor := keystorev4.New()
+		names := make([]string, 0, len(accounts))
+		keystores := make([]string, 0, len(accounts))
+		passwords := make([]string, 0, len(accounts))
+		pubKeys := make([][]byte, 0, len(accounts))
+		for _, selected := range accounts {
+			name := fmt.Sprintf("%s/%s", selected.wallet.Name(), selected.account.Name())
+			assert(selected.wallet.Type() != "distributed", fmt.Sprintf("Account %s is distributed; its key cannot be imported", name))
+			privateKey, err := accountPrivateKey(ctx, selected.account)
+			errCheck(err, fmt.Sprintf("Failed to obtain private key for account %s", name))
+			passphrase := keymanagerImportKeystorePassphrase
+			if passphrase == "" {
+				data := make([]byte, 32)
+				_, err := rand.Read(data)
+				errCheck(err, "Failed to generate passphrase")
+				passphrase = hex.EncodeToString(data)
+			}
+			path := ""
+			if pathProvider, ok := selected.account.(e2wtypes.AccountPathProvider); ok {
+				path = pathProvider.Path()
+			}
+			pubKey := privateKey.PublicKey().Marshal()
+			keystore, err := util.NewKeystore(encryptor, privateKey.Marshal(), pubKey, path, passphrase)
+			errCheck(err, fmt.Sprintf("Failed to create keystore for account %s", name))
+			data, err := json.Marshal(keystore)
+			errCheck(err, fmt.Sprintf("Failed to generate keystore for account %s", name))
+			names = append(names, name)
+			keystores = append(keystores, string(data))
+			passwords = append(passwords, passphrase)
+			pubKeys = append(pubKeys, pubKey)
+		}
+
+		slashingProtection := ""
+		if keymanagerImportSlashingProtection != "" {
+			data, err := ioutil.ReadFile(keymanagerImportSlashingProtection)
+			errCheck(err, "Failed to read slashing protection file")
+			filtered, records, err := util.FilterSlashingProtection(data, pubKeys)
+			errCheck(err, "Invalid slashing protection file")
+			outputIf(verbose, fmt.Sprintf("Slashing protection records found for %d of %d keys", records, len(keystores)))
+			slashingProtection = string(filtered)
+		}
+
+		statuses, err := client.ImportKeystores(ctx, keystores, passwords, slashingProtection)
+		errCheck(err, "Failed to import keys")
+
+		failed := false
+		for i, status := range statuses {
+			switch status.Status {
+			case "imported":
+				outputIf(!quiet, fmt.Sprintf("Imported %#x (%s)", pubKeys[i], names[i]))
+			case "duplicate":
+				outputIf(!quiet, fmt.Sprintf("Already present %#x (%s)", pubKeys[i], names[i]))
+			default:
+				failed = true
+				outputIf(!quiet, fmt.Sprintf("Failed to import %#x (%s): %s", pubKeys[i], names[i], statusDescription(status.Status, status.Message)))
+			}
+		}
+		if failed {
+			os.Exit(_exitFailure)
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+// statusDescription describes the status of a keymanager operation on a single key.
+func statusDescription(status string, message string) string {
+	if message == "" {
+		return status
+	}
+	return fmt.Sprintf("%s (%s)", status, message)
+}
+
+func init() {
+	keymanagerCmd.AddCommand(keymanagerImportCmd)
+	selectFlags(keymanagerImportCmd)
+	keymanagerImportCmd.Flags().StringVar(&keymanagerImportKeystorePassphrase, "keystorepassphrase", "", "Passphrase for the keystores sent to the validator client (defaults to a random passphrase for each keystore)")
+	keymanagerImportCmd.Flags().StringVar(&keymanagerImportSlashingProtection, "slashing-protection", "", "EIP-3076 slashing protection file to send with the keys")
+}
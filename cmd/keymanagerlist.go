@@ -0,0 +1,68 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var keymanagerListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys held by a validator client",
+	Long: `List the keys held by a validator client.  For example:
+
+    ethdo keymanager list --keymanager-url=http://localhost:7500 --keymanager-token-file=/var/lib/lighthouse/validators/api-token.txt
+
+In quiet mode this will return 0 if the validator client holds any keys, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		client, err := keymanagerClient()
+		errCheck(err, "Failed to set up keymanager client")
+		keystores, err := client.ListKeystores(ctx)
+		errCheck(err, "Failed to list keys")
+
+		if quiet {
+			if len(keystores) == 0 {
+				os.Exit(_exitFailure)
+			}
+			os.Exit(_exitSuccess)
+		}
+		for _, keystore := range keystores {
+			if !verbose {
+				fmt.Println(keystore.ValidatingPubKey)
+				continue
+			}
+			fmt.Printf("%s", keystore.ValidatingPubKey)
+			if keystore.DerivationPath != "" {
+				fmt.Printf(" (path %s)", keystore.DerivationPath)
+			}
+			if keystore.ReadOnly {
+				fmt.Print(" read-only")
+			}
+			fmt.Println()
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	keymanagerCmd.AddCommand(keymanagerListCmd)
+}
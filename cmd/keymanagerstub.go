@@ -0,0 +1,57 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethdo/keymanager"
+)
+
+var keymanagerStubListen string
+
+var keymanagerStubCmd = &cobra.Command{
+	Use:   "stub",
+	Short: "Run a stub keymanager API for testing",
+	Long: `Run an in-memory keymanager API, allowing the other keymanager commands to be tried without a validator client.  For example:
+
+    ethdo keymanager stub --listen=localhost:7500 --keymanager-token=secret
+
+The stub requires the token given by --keymanager-token or --keymanager-token-file, or if neither is supplied generates and prints a token.  Keys and settings are lost when the stub exits.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		token, err := keymanagerToken()
+		errCheck(err, "Failed to obtain token")
+		if token == "" {
+			data := make([]byte, 32)
+			_, err := rand.Read(data)
+			errCheck(err, "Failed to generate token")
+			token = hex.EncodeToString(data)
+			fmt.Printf("Token: %s\n", token)
+		}
+		stub, err := keymanager.NewStub(token)
+		errCheck(err, "Failed to create stub keymanager")
+
+		outputIf(!quiet, fmt.Sprintf("Listening on %s", keymanagerStubListen))
+		errCheck(http.ListenAndServe(keymanagerStubListen, stub), "Stub keymanager failed")
+	},
+}
+
+func init() {
+	keymanagerCmd.AddCommand(keymanagerStubCmd)
+	keymanagerStubCmd.Flags().StringVar(&keymanagerStubListen, "listen", "localhost:7500", "Address on which to listen")
+}
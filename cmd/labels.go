@@ -0,0 +1,148 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+const accountLabelsFile = "labels.json"
+
+// accountLabelsRecord holds the labels of an account.  The wallet and account names are held for reference only;
+// accounts are identified by their ID.
+type accountLabelsRecord struct {
+	Wallet  string            `json:"wallet"`
+	Account string            `json:"account"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// accountLabels are the labels of accounts, held in a file alongside the wallets.
+type accountLabels struct {
+	filename string
+	Accounts map[uuid.UUID]*accountLabelsRecord `json:"accounts"`
+}
+
+// accountLabelsFilename returns the file that holds account labels.  This is given by --labels-file, or is held
+// in the base directory of a filesystem store.
+func accountLabelsFilename() (string, error) {
+	if viper.GetString("labels-file") != "" {
+		return viper.GetString("labels-file"), nil
+	}
+	if remote {
+		return "", errors.New("--labels-file is required with remote wallets")
+	}
+	location, err := defaultStoreLocation()
+	if err != nil {
+		return "", errors.Wrap(err, "--labels-file is required for this store")
+	}
+	return filepath.Join(location, accountLabelsFile), nil
+}
+
+// loadAccountLabels loads the account labels.  No labels are returned if the file does not yet exist.
+func loadAccountLabels() (*accountLabels, error) {
+	filename, err := accountLabelsFilename()
+	if err != nil {
+		return nil, err
+	}
+	labels := &accountLabels{
+		filename: filename,
+		Accounts: make(map[uuid.UUID]*accountLabelsRecord),
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return labels, nil
+		}
+		return nil, errors.Wrap(err, "failed to read labels")
+	}
+	if err := json.Unmarshal(data, labels); err != nil {
+		return nil, errors.Wrap(err, "failed to parse labels")
+	}
+	if labels.Accounts == nil {
+		labels.Accounts = make(map[uuid.UUID]*accountLabelsRecord)
+	}
+	return labels, nil
+}
+
+// save writes the account labels, replacing the existing file only once the new one is complete.
+func (l *accountLabels) save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to generate labels")
+	}
+	data = append(data, '\n')
+	if err := os.MkdirAll(filepath.Dir(l.filename), 0700); err != nil {
+		return errors.Wrap(err, "failed to create labels directory")
+	}
+	tmpFilename := l.filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFilename, data, 0600); err != nil {
+		return errors.Wrap(err, "failed to write labels")
+	}
+	if err := os.Rename(tmpFilename, l.filename); err != nil {
+		return errors.Wrap(err, "failed to write labels")
+	}
+	return nil
+}
+
+// labels returns the labels of an account.
+func (l *accountLabels) labels(account e2wtypes.Account) map[string]string {
+	record, exists := l.Accounts[account.ID()]
+	if !exists {
+		return map[string]string{}
+	}
+	return record.Labels
+}
+
+// set sets a label of an account.
+func (l *accountLabels) set(wallet e2wtypes.Wallet, account e2wtypes.Account, key string, value string) {
+	record, exists := l.Accounts[account.ID()]
+	if !exists {
+		record = &accountLabelsRecord{Labels: make(map[string]string)}
+		l.Accounts[account.ID()] = record
+	}
+	record.Wallet = wallet.Name()
+	record.Account = account.Name()
+	record.Labels[key] = value
+}
+
+// remove removes a label from an account.
+func (l *accountLabels) remove(account e2wtypes.Account, key string) {
+	record, exists := l.Accounts[account.ID()]
+	if !exists {
+		return
+	}
+	delete(record.Labels, key)
+	if len(record.Labels) == 0 {
+		delete(l.Accounts, account.ID())
+	}
+}
+
+// sortedLabelKeys returns the keys of a set of labels in order.
+func sortedLabelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,69 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethdo/util"
+	"github.com/wealdtech/go-bytesutil"
+	e2util "github.com/wealdtech/go-eth2-util"
+)
+
+var mnemonicCheckPath string
+var mnemonicCheckPubKey string
+
+// mnemonicCheckCmd represents the mnemonic check command
+var mnemonicCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check a mnemonic against a known public key",
+	Long: `Check that a mnemonic generates a known public key at a given path, for example to confirm that a backup of a mnemonic is correct.  For example:
+
+    ethdo mnemonic check --mnemonic="abandon abandon abandon … art" --path=m/12381/3600/0/0/0 --pubkey=0xb384f767d964e100c8a9b21018d08c25ffebae268b3ab6d610353897541971726dbfc3c7463884c68a531515aab94c87
+
+No wallet is created, and no keys are stored.
+
+In quiet mode this will return 0 if the mnemonic generates the public key, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(mnemonicInput != "", "--mnemonic is required")
+		assert(mnemonicCheckPubKey != "", "--pubkey is required")
+		pubKey, err := bytesutil.FromHexString(mnemonicCheckPubKey)
+		errCheck(err, "Invalid public key")
+		assert(len(pubKey) == 48, "Public key must be 48 bytes")
+
+		seed, err := util.SeedFromMnemonic(mnemonicInput)
+		errCheck(err, "Invalid mnemonic")
+		privateKey, err := e2util.PrivateKeyFromSeedAndPath(seed, mnemonicCheckPath)
+		errCheck(err, "Failed to derive key")
+		derivedPubKey := privateKey.PublicKey().Marshal()
+		outputIf(debug, fmt.Sprintf("Derived public key is %#x", derivedPubKey))
+
+		if !bytes.Equal(derivedPubKey, pubKey) {
+			outputIf(!quiet, fmt.Sprintf("Mnemonic does not generate the public key at path %s", mnemonicCheckPath))
+			os.Exit(_exitFailure)
+		}
+		outputIf(!quiet, fmt.Sprintf("Mnemonic generates the public key at path %s", mnemonicCheckPath))
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	mnemonicCmd.AddCommand(mnemonicCheckCmd)
+	mnemonicFlags(mnemonicCheckCmd)
+	mnemonicCheckCmd.Flags().StringVar(&mnemonicCheckPath, "path", "m/12381/3600/0/0/0", "Path at which to derive the key")
+	mnemonicCheckCmd.Flags().StringVar(&mnemonicCheckPubKey, "pubkey", "", "The known public key")
+}
@@ -0,0 +1,65 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/wealdtech/ethdo/util"
+	"github.com/wealdtech/go-bytesutil"
+)
+
+var mnemonicConvertEntropy string
+
+// mnemonicConvertCmd represents the mnemonic convert command
+var mnemonicConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert between a mnemonic and its entropy",
+	Long: `Convert a mnemonic to its entropy, or entropy to its mnemonic.  For example:
+
+    ethdo mnemonic convert --mnemonic="abandon abandon abandon … art"
+
+    ethdo mnemonic convert --entropy=0x0000000000000000000000000000000000000000000000000000000000000000
+
+In quiet mode this will return 0 if the conversion succeeds, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(mnemonicInput != "" || mnemonicConvertEntropy != "", "one of --mnemonic or --entropy is required")
+		assert(mnemonicInput == "" || mnemonicConvertEntropy == "", "only one of --mnemonic or --entropy is allowed")
+
+		if mnemonicConvertEntropy != "" {
+			entropy, err := bytesutil.FromHexString(mnemonicConvertEntropy)
+			errCheck(err, "Invalid entropy")
+			mnemonic, err := bip39.NewMnemonic(entropy)
+			errCheck(err, "Failed to generate mnemonic")
+			outputIf(!quiet, mnemonic)
+			os.Exit(_exitSuccess)
+		}
+
+		mnemonic, _, err := util.SplitMnemonic(mnemonicInput)
+		errCheck(err, "Invalid mnemonic")
+		entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+		errCheck(err, "Failed to obtain entropy")
+		outputIf(!quiet, fmt.Sprintf("%#x", entropy))
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	mnemonicCmd.AddCommand(mnemonicConvertCmd)
+	mnemonicFlags(mnemonicConvertCmd)
+	mnemonicConvertCmd.Flags().StringVar(&mnemonicConvertEntropy, "entropy", "", "Entropy from which to create a mnemonic, as a hex string")
+}
@@ -0,0 +1,51 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+var mnemonicGenerateWords int
+
+// mnemonicGenerateCmd represents the mnemonic generate command
+var mnemonicGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a mnemonic",
+	Long: `Generate a new random mnemonic.  For example:
+
+    ethdo mnemonic generate --words=24
+
+In quiet mode this will return 0 if the mnemonic is generated, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(mnemonicGenerateWords >= 12 && mnemonicGenerateWords <= 24 && mnemonicGenerateWords%3 == 0, "--words must be one of 12, 15, 18, 21 or 24")
+
+		// Each word encodes 11 bits, of which one in every 33 is checksum.
+		entropy, err := bip39.NewEntropy(mnemonicGenerateWords * 32 / 3)
+		errCheck(err, "Failed to generate entropy")
+		mnemonic, err := bip39.NewMnemonic(entropy)
+		errCheck(err, "Failed to generate mnemonic")
+
+		outputIf(!quiet, mnemonic)
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	mnemonicCmd.AddCommand(mnemonicGenerateCmd)
+	mnemonicGenerateCmd.Flags().IntVar(&mnemonicGenerateWords, "words", 24, "Number of words in the mnemonic (12, 15, 18, 21 or 24)")
+}
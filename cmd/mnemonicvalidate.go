@@ -0,0 +1,58 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/wealdtech/ethdo/util"
+)
+
+// mnemonicValidateCmd represents the mnemonic validate command
+var mnemonicValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate a mnemonic",
+	Long: `Validate a mnemonic, outputting its normalised form.  For example:
+
+    ethdo mnemonic validate --mnemonic="Abandon  abandon abandon … art"
+
+With --verbose the number of words, the presence of a passphrase and the seed fingerprint are also output.  The seed fingerprint is the first 4 bytes of the SHA-256 hash of the master public key, and can be used to confirm that two mnemonics are the same without revealing them.
+
+In quiet mode this will return 0 if the mnemonic is valid, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(mnemonicInput != "", "--mnemonic is required")
+
+		mnemonic, passphrase, err := util.SplitMnemonic(mnemonicInput)
+		errCheck(err, "Invalid mnemonic")
+		outputIf(!quiet, mnemonic)
+		if verbose {
+			fmt.Printf("Words: %d\n", len(strings.Fields(mnemonic)))
+			fmt.Printf("Passphrase: %t\n", passphrase != "")
+			fingerprint, err := util.SeedFingerprint(bip39.NewSeed(mnemonic, passphrase))
+			errCheck(err, "Failed to calculate seed fingerprint")
+			fmt.Printf("Seed fingerprint: %#x\n", fingerprint)
+		}
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	mnemonicCmd.AddCommand(mnemonicValidateCmd)
+	mnemonicFlags(mnemonicValidateCmd)
+}
@@ -0,0 +1,122 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/ethdo/util"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// reencryptRecord re-encrypts the crypto section of a stored wallet or account record with a new passphrase.
+// Each of the old passphrases is tried in turn to decrypt the existing crypto section.
+// If kdfParams is nil the existing key derivation parameters are retained.
+func reencryptRecord(data []byte, oldPassphrases []string, newPassphrase string, kdfParams *util.KDFParams) ([]byte, error) {
+	record := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Retain numbers as they are stored.
+	decoder.UseNumber()
+	if err := decoder.Decode(&record); err != nil {
+		return nil, errors.Wrap(err, "failed to parse record")
+	}
+	if encryptorName, exists := record["encryptor"]; exists && encryptorName != "keystore" {
+		return nil, errors.New("unsupported encryptor")
+	}
+	crypto, ok := record["crypto"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("record has no crypto section")
+	}
+
+	if kdfParams == nil {
+		var err error
+		kdfParams, err = util.KDFParamsFromCrypto(crypto)
+		if err != nil {
+			return nil, err
+		}
+	}
+	encryptor, err := util.NewKeystoreEncryptor(kdfParams)
+	if err != nil {
+		return nil, err
+	}
+	var secret []byte
+	for _, passphrase := range oldPassphrases {
+		var err error
+		secret, err = encryptor.Decrypt(crypto, passphrase)
+		if err == nil {
+			break
+		}
+	}
+	if secret == nil {
+		return nil, errors.New("incorrect passphrase")
+	}
+
+	newCrypto, err := encryptor.Encrypt(secret, newPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt with new passphrase")
+	}
+	// Ensure the new crypto section can be decrypted before it replaces the old one.
+	check, err := encryptor.Decrypt(newCrypto, newPassphrase)
+	if err != nil || !bytes.Equal(check, secret) {
+		return nil, errors.New("failed to confirm encryption with new passphrase")
+	}
+	record["crypto"] = newCrypto
+
+	return json.Marshal(record)
+}
+
+// accountRecordUpdate is an updated account record, along with the original record to restore if required.
+type accountRecordUpdate struct {
+	store    e2wtypes.Store
+	wallet   e2wtypes.Wallet
+	account  e2wtypes.Account
+	original []byte
+	update   []byte
+}
+
+// newAccountRecordUpdate obtains the stored record of a local account, ready for it to be updated.
+func newAccountRecordUpdate(wallet e2wtypes.Wallet, account e2wtypes.Account) (*accountRecordUpdate, error) {
+	storeProvider, ok := wallet.(e2wtypes.StoreProvider)
+	if !ok {
+		return nil, errors.New("cannot obtain store for the wallet")
+	}
+	original, err := storeProvider.Store().RetrieveAccount(wallet.ID(), account.ID())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve account")
+	}
+	return &accountRecordUpdate{
+		store:    storeProvider.Store(),
+		wallet:   wallet,
+		account:  account,
+		original: original,
+	}, nil
+}
+
+// storeAccountRecords stores updated account records, restoring the original records if any store fails.
+func storeAccountRecords(updates []*accountRecordUpdate, action string) {
+	for i, update := range updates {
+		if err := update.store.StoreAccount(update.wallet.ID(), update.account.ID(), update.update); err != nil {
+			for j := i; j >= 0; j-- {
+				if rollbackErr := updates[j].store.StoreAccount(updates[j].wallet.ID(), updates[j].account.ID(), updates[j].original); rollbackErr != nil {
+					outputIf(!quiet, fmt.Sprintf("Failed to roll back account %s/%s: %v", updates[j].wallet.Name(), updates[j].account.Name(), rollbackErr))
+				}
+			}
+			die(fmt.Sprintf("Failed to store account %s/%s: %v", update.wallet.Name(), update.account.Name(), err))
+		}
+		outputIf(verbose, fmt.Sprintf("%s account %s/%s", action, update.wallet.Name(), update.account.Name()))
+	}
+}
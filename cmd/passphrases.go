@@ -13,7 +13,26 @@
 
 package cmd
 
-import "github.com/spf13/viper"
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var newPassphraseFlag *pflag.Flag
+
+// newPassphraseFlags adds the flag that supplies a new passphrase to a command.
+func newPassphraseFlags(cmd *cobra.Command) {
+	if newPassphraseFlag == nil {
+		cmd.Flags().String("newpassphrase", "", "New passphrase")
+		newPassphraseFlag = cmd.Flags().Lookup("newpassphrase")
+		if err := viper.BindPFlag("new-passphrase", newPassphraseFlag); err != nil {
+			panic(err)
+		}
+	} else {
+		cmd.Flags().AddFlag(newPassphraseFlag)
+	}
+}
 
 // getStorePassphrases() fetches the store passphrase supplied by the user.
 func getStorePassphrase() string {
@@ -47,3 +66,8 @@ func getOptionalPassphrase() string {
 	assert(len(passphrases) == 1, "multiple passphrases supplied; cannot continue")
 	return passphrases[0]
 }
+
+// getNewPassphrase fetches the new passphrase supplied by the user.
+func getNewPassphrase() string {
+	return viper.GetString("new-passphrase")
+}
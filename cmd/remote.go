@@ -0,0 +1,307 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// remoteCmd represents the remote command
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Manage remote wallet daemons",
+	Long:  `Obtain information about remote wallet daemons.`,
+}
+
+func init() {
+	RootCmd.AddCommand(remoteCmd)
+}
+
+func remoteFlags(cmd *cobra.Command) {
+}
+
+// remoteAddresses returns the addresses of the remote wallet daemons supplied with --remote.
+func remoteAddresses() []string {
+	res := make([]string, 0)
+	for _, address := range strings.Split(viper.GetString("remote"), ",") {
+		address = strings.TrimSpace(address)
+		if address != "" {
+			res = append(res, address)
+		}
+	}
+	return res
+}
+
+// remoteTLS holds the per-endpoint TLS settings supplied with --remote-tls.
+var remoteTLS []string
+
+// remoteTLSSettings returns the per-endpoint TLS settings from --remote-tls or the remote-tls configuration item.
+// viper cannot decode string array flags, so values given on the command line are taken from the flag directly.
+func remoteTLSSettings() []string {
+	if len(remoteTLS) > 0 {
+		return remoteTLS
+	}
+	if !viper.IsSet("remote-tls") {
+		return nil
+	}
+	return viper.GetStringSlice("remote-tls")
+}
+
+// remoteCredentials composes the transport credentials for the remote wallet daemons.  Credentials for individual
+// endpoints are taken from --remote-tls if present, otherwise from --client-cert, --client-key and --server-ca-cert.
+func remoteCredentials(ctx context.Context) (credentials.TransportCredentials, error) {
+	res := &endpointCredentials{
+		endpoints: make(map[string]credentials.TransportCredentials),
+	}
+	for _, setting := range remoteTLSSettings() {
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid remote TLS setting %q", setting)
+		}
+		files := strings.Split(parts[1], ",")
+		if len(files) < 2 || len(files) > 3 {
+			return nil, fmt.Errorf("invalid remote TLS setting %q", setting)
+		}
+		caCert := ""
+		if len(files) == 3 {
+			caCert = files[2]
+		}
+		var err error
+		res.endpoints[parts[0]], err = dirk.ComposeCredentials(ctx, files[0], files[1], caCert)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to build credentials for %s", parts[0]))
+		}
+	}
+
+	for _, address := range remoteAddresses() {
+		if _, exists := res.endpoints[address]; exists {
+			continue
+		}
+		if res.defaultCredentials == nil {
+			if viper.GetString("client-cert") == "" {
+				return nil, errors.New("remote connections require client-cert")
+			}
+			if viper.GetString("client-key") == "" {
+				return nil, errors.New("remote connections require client-key")
+			}
+			var err error
+			res.defaultCredentials, err = dirk.ComposeCredentials(ctx, viper.GetString("client-cert"), viper.GetString("client-key"), viper.GetString("server-ca-cert"))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to build dirk credentials")
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// endpointCredentials are transport credentials that select the credentials to use by endpoint.
+type endpointCredentials struct {
+	defaultCredentials credentials.TransportCredentials
+	endpoints          map[string]credentials.TransportCredentials
+}
+
+// credentialsFor returns the credentials for the given endpoint.
+func (c *endpointCredentials) credentialsFor(authority string) (credentials.TransportCredentials, error) {
+	if res, exists := c.endpoints[authority]; exists {
+		return res, nil
+	}
+	if c.defaultCredentials == nil {
+		return nil, fmt.Errorf("no credentials for %s", authority)
+	}
+	return c.defaultCredentials, nil
+}
+
+// ClientHandshake carries out the client handshake with the credentials for the endpoint.
+func (c *endpointCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := c.credentialsFor(authority)
+	if err != nil {
+		return nil, nil, err
+	}
+	return creds.ClientHandshake(ctx, authority, rawConn)
+}
+
+// ServerHandshake is not supported.
+func (c *endpointCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("server handshakes not supported")
+}
+
+// Info provides the protocol information.
+func (c *endpointCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{
+		SecurityProtocol: "tls",
+	}
+}
+
+// Clone clones the credentials.
+func (c *endpointCredentials) Clone() credentials.TransportCredentials {
+	res := &endpointCredentials{
+		endpoints: make(map[string]credentials.TransportCredentials, len(c.endpoints)),
+	}
+	if c.defaultCredentials != nil {
+		res.defaultCredentials = c.defaultCredentials.Clone()
+	}
+	for k, v := range c.endpoints {
+		res.endpoints[k] = v.Clone()
+	}
+	return res
+}
+
+// OverrideServerName is not supported, as server names are per-endpoint.
+func (c *endpointCredentials) OverrideServerName(serverNameOverride string) error {
+	return errors.New("server name override not supported")
+}
+
+// remoteEndpointStatus is the status of a remote wallet daemon.
+type remoteEndpointStatus struct {
+	address           string
+	err               error
+	latency           time.Duration
+	tlsVersion        uint16
+	serverName        string
+	certificateExpiry time.Time
+}
+
+// probeRemote connects to a remote wallet daemon to confirm that it is reachable and that the TLS handshake succeeds.
+func probeRemote(ctx context.Context, creds credentials.TransportCredentials, address string) *remoteEndpointStatus {
+	res := &remoteEndpointStatus{
+		address: address,
+	}
+	started := time.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		res.err = errors.Wrap(err, "failed to connect")
+		return res
+	}
+	defer conn.Close()
+	tlsConn, authInfo, err := creds.ClientHandshake(ctx, address, conn)
+	if err != nil {
+		res.err = errors.Wrap(err, "TLS handshake failed")
+		return res
+	}
+	defer tlsConn.Close()
+	res.latency = time.Since(started)
+
+	if tlsInfo, isTLSInfo := authInfo.(credentials.TLSInfo); isTLSInfo {
+		res.tlsVersion = tlsInfo.State.Version
+		if len(tlsInfo.State.PeerCertificates) > 0 {
+			res.serverName = tlsInfo.State.PeerCertificates[0].Subject.CommonName
+			res.certificateExpiry = tlsInfo.State.PeerCertificates[0].NotAfter
+		}
+	}
+	return res
+}
+
+// probeRemotes probes the supplied remote wallet daemons in parallel, returning their statuses in the order supplied.
+func probeRemotes(ctx context.Context, creds credentials.TransportCredentials, addresses []string) []*remoteEndpointStatus {
+	res := make([]*remoteEndpointStatus, len(addresses))
+	var wg sync.WaitGroup
+	for i := range addresses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res[i] = probeRemote(ctx, creds, addresses[i])
+		}(i)
+	}
+	wg.Wait()
+	return res
+}
+
+// remoteEndpoints returns the endpoints of the remote wallet daemons.  If there are multiple endpoints they are
+// probed, and reachable endpoints are placed first so that operations that use a single endpoint fail over.
+func remoteEndpoints(ctx context.Context, creds credentials.TransportCredentials) ([]*dirk.Endpoint, error) {
+	addresses := remoteAddresses()
+	if len(addresses) > 1 {
+		reachable := make([]string, 0, len(addresses))
+		unreachable := make([]string, 0)
+		for _, status := range probeRemotes(ctx, creds, addresses) {
+			if status.err != nil {
+				outputIf(debug, fmt.Sprintf("Remote %s is unreachable: %v", status.address, status.err))
+				unreachable = append(unreachable, status.address)
+			} else {
+				reachable = append(reachable, status.address)
+			}
+		}
+		if len(reachable) == 0 {
+			return nil, errors.New("no remote endpoints are reachable")
+		}
+		addresses = append(reachable, unreachable...)
+	}
+	return remotesToEndpoints(addresses)
+}
+
+// remoteConnection connects to a remote wallet daemon.
+func remoteConnection(ctx context.Context, creds credentials.TransportCredentials, address string) (*grpc.ClientConn, error) {
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(creds), grpc.WithBlock())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to connect to %s", address))
+	}
+	return conn, nil
+}
+
+// withRemoteConnection connects to a remote wallet daemon and calls fn with the connection.  Each call has its own
+// timeout, so that an unresponsive daemon does not use up the time available to the daemons contacted after it.
+func withRemoteConnection(creds credentials.TransportCredentials, address string, fn func(ctx context.Context, conn *grpc.ClientConn) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+	defer cancel()
+	conn, err := remoteConnection(ctx, creds, address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(ctx, conn)
+}
+
+// tlsVersionName returns the name of a TLS version.
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "1.0"
+	case tls.VersionTLS11:
+		return "1.1"
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	default:
+		return fmt.Sprintf("unknown (%#04x)", version)
+	}
+}
+
+// responseStateDescription describes the state of an unsuccessful response from a remote wallet daemon.
+func responseStateDescription(state pb.ResponseState) string {
+	switch state {
+	case pb.ResponseState_DENIED:
+		return "denied"
+	case pb.ResponseState_FAILED:
+		return "failed"
+	default:
+		return fmt.Sprintf("returned unexpected state %v", state)
+	}
+}
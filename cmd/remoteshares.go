@@ -0,0 +1,112 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc"
+)
+
+// remoteSharesCmd represents the remote shares command
+var remoteSharesCmd = &cobra.Command{
+	Use:   "shares",
+	Short: "Show which remote wallet daemons hold shares of a distributed account",
+	Long: `Show which remote wallet daemons hold shares of a distributed account.  For example:
+
+    ethdo remote shares --account="Validators/1" --remote=dirk1.example.com:13141,dirk2.example.com:13141,dirk3.example.com:13141
+
+Each participant of the account is contacted to confirm that it holds its share.
+
+In quiet mode this will return 0 if all participants hold their shares, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(remote, "remote shares is only available with remote wallets")
+		assert(viper.GetString("account") != "", "--account is required")
+
+		wallet, account, err := walletAndAccountFromInput(ctx)
+		errCheck(err, "Failed to obtain account")
+		distributedAccount, isDistributedAccount := account.(e2wtypes.DistributedAccount)
+		assert(isDistributedAccount, "Account is not a distributed account")
+		_, accountName, err := e2wallet.WalletAndAccountNames(viper.GetString("account"))
+		errCheck(err, "Failed to obtain account name")
+		compositePubKey := distributedAccount.CompositePublicKey().Marshal()
+
+		credentials, err := remoteCredentials(ctx)
+		errCheck(err, "Failed to obtain remote credentials")
+
+		outputIf(!quiet, fmt.Sprintf("Composite public key: %#x", compositePubKey))
+		outputIf(!quiet, fmt.Sprintf("Signing threshold: %d/%d", distributedAccount.SigningThreshold(), len(distributedAccount.Participants())))
+		ids := make([]uint64, 0, len(distributedAccount.Participants()))
+		for id := range distributedAccount.Participants() {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i int, j int) bool { return ids[i] < ids[j] })
+
+		held := 0
+		for _, id := range ids {
+			address := distributedAccount.Participants()[id]
+			var share *pb.DistributedAccount
+			connected := false
+			err := withRemoteConnection(credentials, address, func(ctx context.Context, conn *grpc.ClientConn) error {
+				connected = true
+				resp, err := pb.NewListerClient(conn).ListAccounts(ctx, &pb.ListAccountsRequest{
+					Paths: []string{fmt.Sprintf("%s/%s", wallet.Name(), accountName)},
+				})
+				if err != nil || resp.State != pb.ResponseState_SUCCEEDED {
+					return errors.New("failed to list accounts")
+				}
+				for _, candidate := range resp.DistributedAccounts {
+					if bytes.Equal(candidate.CompositePublicKey, compositePubKey) {
+						share = candidate
+						return nil
+					}
+				}
+				return errors.New("does not hold a share")
+			})
+			if err != nil {
+				if !connected {
+					err = errors.New("unreachable")
+				}
+				outputIf(!quiet, fmt.Sprintf("Participant %d (%s): %v", id, address, err))
+				continue
+			}
+			held++
+			outputIf(!quiet && !verbose, fmt.Sprintf("Participant %d (%s): holds share", id, address))
+			outputIf(verbose, fmt.Sprintf("Participant %d (%s): holds share with public key %#x", id, address, share.PublicKey))
+		}
+
+		if held != len(ids) {
+			os.Exit(_exitFailure)
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	remoteCmd.AddCommand(remoteSharesCmd)
+	remoteFlags(remoteSharesCmd)
+}
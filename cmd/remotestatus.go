@@ -0,0 +1,92 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+)
+
+// remoteStatusCmd represents the remote status command
+var remoteStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Obtain the status of remote wallet daemons",
+	Long: `Obtain the status of each remote wallet daemon.  For example:
+
+    ethdo remote status --remote=dirk1.example.com:13141,dirk2.example.com:13141,dirk3.example.com:13141 --client-cert=client.crt --client-key=client.key --server-ca-cert=ca.crt
+
+If --wallet is supplied the number of accounts in the wallet held by each daemon is also shown.
+
+The software version of each daemon is not shown, as Dirk does not expose its version over its API.
+
+In quiet mode this will return 0 if all remote wallet daemons are reachable, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		addresses := remoteAddresses()
+		assert(len(addresses) > 0, "--remote is required")
+		endpoints, err := remotesToEndpoints(addresses)
+		errCheck(err, "Failed to parse remote servers")
+		credentials, err := remoteCredentials(ctx)
+		errCheck(err, "Failed to obtain remote credentials")
+
+		reachable := 0
+		for i, status := range probeRemotes(ctx, credentials, addresses) {
+			if status.err != nil {
+				outputIf(!quiet, fmt.Sprintf("%s: unreachable (%v)", status.address, status.err))
+				continue
+			}
+			reachable++
+			if quiet {
+				continue
+			}
+			fmt.Printf("%s: reachable (%v)\n", status.address, status.latency.Round(time.Microsecond))
+			fmt.Printf("  TLS version: %s\n", tlsVersionName(status.tlsVersion))
+			if status.serverName != "" {
+				fmt.Printf("  Server certificate: %s\n", status.serverName)
+			}
+			outputIf(verbose && !status.certificateExpiry.IsZero(), fmt.Sprintf("  Server certificate expiry: %v", status.certificateExpiry))
+			if viper.GetString("wallet") != "" {
+				wallet, err := dirk.OpenWallet(ctx, viper.GetString("wallet"), credentials, endpoints[i:i+1])
+				if err != nil {
+					fmt.Printf("  Failed to access wallet %q: %v\n", viper.GetString("wallet"), err)
+					continue
+				}
+				accounts := 0
+				for range wallet.Accounts(ctx) {
+					accounts++
+				}
+				fmt.Printf("  Accounts in wallet %q: %d\n", wallet.Name(), accounts)
+			}
+		}
+
+		if reachable != len(addresses) {
+			os.Exit(_exitFailure)
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	remoteCmd.AddCommand(remoteStatusCmd)
+	remoteFlags(remoteStatusCmd)
+	walletFlags(remoteStatusCmd)
+}
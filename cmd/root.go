@@ -15,8 +15,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -27,11 +29,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
 	e2wallet "github.com/wealdtech/go-eth2-wallet"
 	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
 	filesystem "github.com/wealdtech/go-eth2-wallet-store-filesystem"
-	s3 "github.com/wealdtech/go-eth2-wallet-store-s3"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 	"google.golang.org/grpc"
 )
@@ -98,31 +100,51 @@ func persistentPreRun(cmd *cobra.Command, args []string) {
 
 	if viper.GetString("remote") == "" {
 		// Set up our wallet store
-		switch rootStore {
-		case "s3":
-			assert(viper.GetString("base-dir") == "", "--basedir does not apply for the s3 store")
-			var err error
-			store, err = s3.New(s3.WithPassphrase([]byte(getStorePassphrase())))
-			errCheck(err, "Failed to access Amazon S3 wallet store")
-		case "filesystem":
-			opts := make([]filesystem.Option, 0)
-			if getStorePassphrase() != "" {
-				opts = append(opts, filesystem.WithPassphrase([]byte(getStorePassphrase())))
-			}
-			if viper.GetString("base-dir") != "" {
-				opts = append(opts, filesystem.WithLocation(viper.GetString("base-dir")))
-			}
-			store = filesystem.New(opts...)
-		default:
-			die(fmt.Sprintf("Unsupported wallet store %s", rootStore))
-		}
-		err := e2wallet.UseStore(store)
+		var err error
+		store, err = newStore(rootStore, viper.GetString("base-dir"), getStorePassphrase())
+		errCheck(err, "Failed to access wallet store")
+		err = e2wallet.UseStore(store)
 		errCheck(err, "Failed to use defined wallet store")
 	} else {
 		remote = true
 	}
 }
 
+// newStore creates a wallet store of the given type.
+func newStore(name string, baseDir string, passphrase string) (e2wtypes.Store, error) {
+	switch name {
+	case "s3":
+		if baseDir != "" {
+			return nil, errors.New("--basedir does not apply for the s3 store")
+		}
+		store, err := util.NewS3Store(&util.S3StoreParams{
+			Bucket:          viper.GetString("store-s3-bucket"),
+			Region:          viper.GetString("store-s3-region"),
+			Endpoint:        viper.GetString("store-s3-endpoint"),
+			PathStyle:       viper.GetBool("store-s3-path-style"),
+			Profile:         viper.GetString("store-s3-profile"),
+			AccessKeyID:     viper.GetString("store-s3-access-key-id"),
+			SecretAccessKey: viper.GetString("store-s3-secret-access-key"),
+			Passphrase:      []byte(passphrase),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to access S3 wallet store")
+		}
+		return store, nil
+	case "filesystem":
+		opts := make([]filesystem.Option, 0)
+		if passphrase != "" {
+			opts = append(opts, filesystem.WithPassphrase([]byte(passphrase)))
+		}
+		if baseDir != "" {
+			opts = append(opts, filesystem.WithLocation(baseDir))
+		}
+		return filesystem.New(opts...), nil
+	default:
+		return nil, fmt.Errorf("unsupported wallet store %s", name)
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -150,6 +172,34 @@ func init() {
 	if err := viper.BindPFlag("store", RootCmd.PersistentFlags().Lookup("store")); err != nil {
 		panic(err)
 	}
+	RootCmd.PersistentFlags().String("store-s3-bucket", "", "Bucket for the s3 store (defaults to a bucket generated from the access key ID)")
+	if err := viper.BindPFlag("store-s3-bucket", RootCmd.PersistentFlags().Lookup("store-s3-bucket")); err != nil {
+		panic(err)
+	}
+	RootCmd.PersistentFlags().String("store-s3-region", "", "Region for the s3 store (defaults to us-east-1)")
+	if err := viper.BindPFlag("store-s3-region", RootCmd.PersistentFlags().Lookup("store-s3-region")); err != nil {
+		panic(err)
+	}
+	RootCmd.PersistentFlags().String("store-s3-endpoint", "", "Endpoint for the s3 store, for S3-compatible services (defaults to Amazon S3)")
+	if err := viper.BindPFlag("store-s3-endpoint", RootCmd.PersistentFlags().Lookup("store-s3-endpoint")); err != nil {
+		panic(err)
+	}
+	RootCmd.PersistentFlags().Bool("store-s3-path-style", false, "Use path-style addressing for the s3 store")
+	if err := viper.BindPFlag("store-s3-path-style", RootCmd.PersistentFlags().Lookup("store-s3-path-style")); err != nil {
+		panic(err)
+	}
+	RootCmd.PersistentFlags().String("store-s3-profile", "", "Shared credentials profile for the s3 store")
+	if err := viper.BindPFlag("store-s3-profile", RootCmd.PersistentFlags().Lookup("store-s3-profile")); err != nil {
+		panic(err)
+	}
+	RootCmd.PersistentFlags().String("store-s3-access-key-id", "", "Access key ID for the s3 store")
+	if err := viper.BindPFlag("store-s3-access-key-id", RootCmd.PersistentFlags().Lookup("store-s3-access-key-id")); err != nil {
+		panic(err)
+	}
+	RootCmd.PersistentFlags().String("store-s3-secret-access-key", "", "Secret access key for the s3 store")
+	if err := viper.BindPFlag("store-s3-secret-access-key", RootCmd.PersistentFlags().Lookup("store-s3-secret-access-key")); err != nil {
+		panic(err)
+	}
 	RootCmd.PersistentFlags().String("account", "", "Account name (in format \"wallet/account\")")
 	if err := viper.BindPFlag("account", RootCmd.PersistentFlags().Lookup("account")); err != nil {
 		panic(err)
@@ -190,7 +240,7 @@ func init() {
 	if err := viper.BindPFlag("timeout", RootCmd.PersistentFlags().Lookup("timeout")); err != nil {
 		panic(err)
 	}
-	RootCmd.PersistentFlags().String("remote", "", "connection to a remote wallet daemon")
+	RootCmd.PersistentFlags().String("remote", "", "connection to a remote wallet daemon (comma-separated for multiple daemons)")
 	if err := viper.BindPFlag("remote", RootCmd.PersistentFlags().Lookup("remote")); err != nil {
 		panic(err)
 	}
@@ -206,6 +256,10 @@ func init() {
 	if err := viper.BindPFlag("server-ca-cert", RootCmd.PersistentFlags().Lookup("server-ca-cert")); err != nil {
 		panic(err)
 	}
+	RootCmd.PersistentFlags().StringArrayVar(&remoteTLS, "remote-tls", nil, "TLS settings for an individual remote wallet daemon, as host:port=client-cert,client-key[,server-ca-cert] (supply once for each daemon)")
+	if err := viper.BindPFlag("remote-tls", RootCmd.PersistentFlags().Lookup("remote-tls")); err != nil {
+		panic(err)
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -244,6 +298,38 @@ func outputIf(condition bool, msg string) {
 	}
 }
 
+// logActivity appends a record of an action to the activity log.
+func logActivity(action string, details map[string]string) error {
+	logFile := viper.GetString("log")
+	if logFile == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain home directory")
+		}
+		logFile = filepath.Join(home, "ethdo.log")
+	}
+	record := map[string]string{
+		"time":   time.Now().UTC().Format(time.RFC3339),
+		"action": action,
+	}
+	for k, v := range details {
+		record[k] = v
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to create log record")
+	}
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open log file")
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write log record")
+	}
+	return nil
+}
+
 // walletFromInput obtains a wallet given the information in the viper variable
 // "account", or if not present the viper variable "wallet".
 func walletFromInput(ctx context.Context) (e2wtypes.Wallet, error) {
@@ -260,16 +346,14 @@ func walletFromPath(ctx context.Context, path string) (e2wtypes.Wallet, error) {
 		return nil, err
 	}
 	if viper.GetString("remote") != "" {
-		assert(viper.GetString("client-cert") != "", "remote connections require client-cert")
-		assert(viper.GetString("client-key") != "", "remote connections require client-key")
-		credentials, err := dirk.ComposeCredentials(ctx, viper.GetString("client-cert"), viper.GetString("client-key"), viper.GetString("server-ca-cert"))
+		credentials, err := remoteCredentials(ctx)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to build dirk credentials")
+			return nil, err
 		}
 
-		endpoints, err := remotesToEndpoints([]string{viper.GetString("remote")})
+		endpoints, err := remoteEndpoints(ctx, credentials)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse remote servers")
+			return nil, errors.Wrap(err, "failed to obtain remote servers")
 		}
 
 		return dirk.OpenWallet(ctx, walletName, credentials, endpoints)
@@ -421,6 +505,28 @@ func remotesToEndpoints(remotes []string) ([]*dirk.Endpoint, error) {
 	return endpoints, nil
 }
 
+// accountPrivateKey obtains the private key of an account, unlocking it with the supplied passphrases if required.
+func accountPrivateKey(ctx context.Context, account e2wtypes.Account) (e2types.PrivateKey, error) {
+	privateKeyProvider, isPrivateKeyProvider := account.(e2wtypes.AccountPrivateKeyProvider)
+	if !isPrivateKeyProvider {
+		return nil, errors.New("account does not provide its private key")
+	}
+	alreadyUnlocked, err := unlock(account)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := privateKeyProvider.PrivateKey(ctx)
+	if !alreadyUnlocked {
+		if err := lock(account); err != nil {
+			return nil, errors.Wrap(err, "failed to lock account")
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain private key")
+	}
+	return privateKey, nil
+}
+
 // relockAccount locks an account; generally called as a defer after an account is unlocked.
 func relockAccount(locker e2wtypes.AccountLocker) {
 	errCheck(locker.Lock(context.Background()), "failed to re-lock account")
@@ -0,0 +1,155 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var selectFlagSet []*pflag.Flag
+
+// selectedAccount is an account selected for an operation, along with its wallet.
+type selectedAccount struct {
+	wallet  e2wtypes.Wallet
+	account e2wtypes.Account
+}
+
+// selectFlags adds the flags that select accounts by their wallet, name and labels to a command.
+func selectFlags(cmd *cobra.Command) {
+	if cmd.Flags().Lookup("wallet") == nil {
+		walletFlags(cmd)
+	}
+	if selectFlagSet == nil {
+		cmd.Flags().String("select", "", "Select accounts by wallet, account and label, for example 'label:region=eu AND wallet:Validators'")
+		cmd.Flags().String("labels-file", "", "File holding account labels (defaults to labels.json in the base directory of a filesystem store)")
+		for _, name := range []string{"select", "labels-file"} {
+			flag := cmd.Flags().Lookup(name)
+			if err := viper.BindPFlag(name, flag); err != nil {
+				panic(err)
+			}
+			selectFlagSet = append(selectFlagSet, flag)
+		}
+	} else {
+		for _, flag := range selectFlagSet {
+			cmd.Flags().AddFlag(flag)
+		}
+	}
+}
+
+// accountsFromInput obtains the accounts given by --select if supplied, otherwise those matching the account
+// specification in path.
+func accountsFromInput(ctx context.Context, path string) ([]*selectedAccount, error) {
+	if viper.GetString("select") != "" {
+		if path != "" {
+			return nil, errors.New("accounts can be given by name or --select, but not both")
+		}
+		return selectedAccountsFromInput(ctx)
+	}
+	wallet, accounts, err := walletAndAccountsFromPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]*selectedAccount, len(accounts))
+	for i, account := range accounts {
+		selected[i] = &selectedAccount{wallet: wallet, account: account}
+	}
+	return selected, nil
+}
+
+// selectedAccountsFromInput obtains the accounts that match --select, ordered by wallet and account name.
+func selectedAccountsFromInput(ctx context.Context) ([]*selectedAccount, error) {
+	selector, err := util.ParseSelector(viper.GetString("select"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid selection")
+	}
+	labels, err := loadAccountLabels()
+	if err != nil {
+		return nil, err
+	}
+	wallets, err := walletsToSearch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]*selectedAccount, 0)
+	for _, wallet := range wallets {
+		for account := range wallet.Accounts(ctx) {
+			item := &util.SelectorItem{
+				WalletName:  wallet.Name(),
+				AccountName: account.Name(),
+				Labels:      labels.labels(account),
+			}
+			if selector.Matches(item) {
+				selected = append(selected, &selectedAccount{wallet: wallet, account: account})
+			}
+		}
+	}
+	sort.Slice(selected, func(i int, j int) bool {
+		if selected[i].wallet.Name() != selected[j].wallet.Name() {
+			return selected[i].wallet.Name() < selected[j].wallet.Name()
+		}
+		return selected[i].account.Name() < selected[j].account.Name()
+	})
+	return selected, nil
+}
+
+// walletsToSearch obtains the wallets to search for accounts.  These are all wallets in the store, or for remote
+// wallets those given in --wallet, as remote wallet daemons do not provide a list of their wallets.
+func walletsToSearch(ctx context.Context) ([]e2wtypes.Wallet, error) {
+	wallets := make([]e2wtypes.Wallet, 0)
+	if !remote {
+		if viper.GetString("wallet") != "" {
+			return nil, errors.New("--wallet applies only to remote wallets; all local wallets are searched")
+		}
+		for wallet := range e2wallet.Wallets() {
+			wallets = append(wallets, wallet)
+		}
+		if err := storeListError(store); err != nil {
+			return nil, errors.Wrap(err, "failed to list wallets")
+		}
+		return wallets, nil
+	}
+
+	if viper.GetString("wallet") == "" {
+		return nil, errors.New("--wallet is required with remote wallets")
+	}
+	credentials, err := remoteCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := remoteEndpoints(ctx, credentials)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain remote servers")
+	}
+	for _, walletName := range strings.Split(viper.GetString("wallet"), ",") {
+		wallet, err := dirk.OpenWallet(ctx, strings.TrimSpace(walletName), credentials, endpoints)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to open remote wallet %s", walletName))
+		}
+		wallets = append(wallets, wallet)
+	}
+	return wallets, nil
+}
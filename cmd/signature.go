@@ -14,9 +14,15 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/go-bytesutil"
 )
 
 // signatureCmd represents the signature command
@@ -51,3 +57,24 @@ func signatureFlags(cmd *cobra.Command) {
 		cmd.Flags().AddFlag(domainFlag)
 	}
 }
+
+// signerPublicKey obtains the public key for a signer, which can be either a hex public key
+// or an account in the format "wallet/account".
+func signerPublicKey(ctx context.Context, signer string) ([]byte, error) {
+	if strings.HasPrefix(signer, "0x") {
+		pubKey, err := bytesutil.FromHexString(signer)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to decode public key %s", signer))
+		}
+		return pubKey, nil
+	}
+	_, account, err := walletAndAccountFromPath(ctx, signer)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain account %s", signer))
+	}
+	pubKey, err := bestPublicKey(account)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain public key for account %s", signer))
+	}
+	return pubKey.Marshal(), nil
+}
@@ -41,7 +41,7 @@ Signatures are specified as "signature" for simple aggregation, and as "id:signa
 In quiet mode this will return 0 if the signatures can be aggregated, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		assert(len(signatureAggregateSignatures) > 1, "multiple signatures required to aggregate")
-		var signature *bls.Sign
+		var signature []byte
 		var err error
 		if strings.Contains(signatureAggregateSignatures[0], ":") {
 			signature, err = generateThresholdSignature()
@@ -50,15 +50,13 @@ In quiet mode this will return 0 if the signatures can be aggregated, otherwise
 		}
 		errCheck(err, "Failed to aggregate signature")
 
-		outputIf(!quiet, fmt.Sprintf("%#x", signature.Serialize()))
+		outputIf(!quiet, fmt.Sprintf("%#x", signature))
 		os.Exit(_exitSuccess)
 	},
 }
 
-func generateThresholdSignature() (*bls.Sign, error) {
-	ids := make([]bls.ID, len(signatureAggregateSignatures))
-	sigs := make([]bls.Sign, len(signatureAggregateSignatures))
-
+func generateThresholdSignature() ([]byte, error) {
+	signatures := make(map[uint64][]byte, len(signatureAggregateSignatures))
 	for i := range signatureAggregateSignatures {
 		parts := strings.Split(signatureAggregateSignatures[i], ":")
 		if len(parts) != 2 {
@@ -68,25 +66,20 @@ func generateThresholdSignature() (*bls.Sign, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "invalid threshold signature ID")
 		}
-		ids[i] = *util.BLSID(id)
+		if _, exists := signatures[id]; exists {
+			return nil, fmt.Errorf("duplicate threshold signature ID %d", id)
+		}
 		sigBytes, err := hex.DecodeString(strings.TrimPrefix(parts[1], "0x"))
 		if err != nil {
-			return nil, errors.Wrap(err, "invalid threshold signature ID")
-		}
-		if err := sigs[i].Deserialize(sigBytes); err != nil {
-			return nil, errors.Wrap(err, "invalid signature")
+			return nil, errors.Wrap(err, "failed to decode signature")
 		}
+		signatures[id] = sigBytes
 	}
 
-	var compositeSig bls.Sign
-	if err := compositeSig.Recover(sigs, ids); err != nil {
-		return nil, err
-	}
-
-	return &compositeSig, nil
+	return util.RecoverThresholdSignature(signatures)
 }
 
-func generateAggregateSignature() (*bls.Sign, error) {
+func generateAggregateSignature() ([]byte, error) {
 	sigs := make([]bls.Sign, len(signatureAggregateSignatures))
 	for i := range signatureAggregateSignatures {
 		sigBytes, err := hex.DecodeString(strings.TrimPrefix(signatureAggregateSignatures[i], "0x"))
@@ -100,7 +93,7 @@ func generateAggregateSignature() (*bls.Sign, error) {
 	var aggregateSig bls.Sign
 	aggregateSig.Aggregate(sigs)
 
-	return &aggregateSig, nil
+	return aggregateSig.Serialize(), nil
 }
 
 func init() {
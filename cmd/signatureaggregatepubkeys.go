@@ -0,0 +1,62 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+)
+
+var signatureAggregatePubKeysPubKeys []string
+
+// signatureAggregatePubKeysCmd represents the signature aggregate-pubkeys command
+var signatureAggregatePubKeysCmd = &cobra.Command{
+	Use:   "aggregate-pubkeys",
+	Short: "Aggregate public keys",
+	Long: `Aggregate public keys in to a single public key.  For example:
+
+    ethdo signature aggregate-pubkeys --pubkey=0xa99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c --pubkey="Validators/1"
+
+Public keys can be supplied as hex strings or as accounts.
+
+In quiet mode this will return 0 if the public keys can be aggregated, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(len(signatureAggregatePubKeysPubKeys) > 1, "multiple public keys required to aggregate")
+		pubKeys := make([][]byte, len(signatureAggregatePubKeysPubKeys))
+		for i := range signatureAggregatePubKeysPubKeys {
+			var err error
+			pubKeys[i], err = signerPublicKey(ctx, signatureAggregatePubKeysPubKeys[i])
+			errCheck(err, "Failed to obtain public key")
+		}
+
+		aggregatePubKey, err := util.AggregatePublicKeys(pubKeys)
+		errCheck(err, "Failed to aggregate public keys")
+
+		outputIf(!quiet, fmt.Sprintf("%#x", aggregatePubKey))
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	signatureCmd.AddCommand(signatureAggregatePubKeysCmd)
+	signatureAggregatePubKeysCmd.Flags().StringArrayVar(&signatureAggregatePubKeysPubKeys, "pubkey", nil, "a public key or account to aggregate (supply once for each public key)")
+}
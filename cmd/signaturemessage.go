@@ -0,0 +1,139 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	util "github.com/wealdtech/go-eth2-util"
+)
+
+// messageDomainType is the domain type for signed messages.  It has the application
+// domain mask set, so cannot clash with any beacon chain domain type.
+var messageDomainType = e2types.DomainType{0x6d, 0x73, 0x67, 0x01}
+
+// messageDomain returns the domain for signed messages.  Messages are not tied to a
+// chain, so the domain uses the zero fork version and genesis validators root.
+func messageDomain() []byte {
+	return e2types.Domain(messageDomainType, e2types.ZeroForkVersion, e2types.ZeroGenesisValidatorsRoot)
+}
+
+// messageRoot returns the root of a message to be signed.
+func messageRoot(message []byte) [32]byte {
+	var root [32]byte
+	copy(root[:], util.SHA256(message))
+	return root
+}
+
+// readMessage reads a message from a file, or from stdin if the path is "-".
+func readMessage(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// signedMessage is a message with its signature.
+type signedMessage struct {
+	Message   []byte
+	PublicKey []byte
+	Domain    []byte
+	Signature []byte
+}
+
+type signedMessageJSON struct {
+	Message   string `json:"message"`
+	Encoding  string `json:"encoding"`
+	PublicKey string `json:"pubkey"`
+	Domain    string `json:"domain"`
+	Signature string `json:"signature"`
+	Version   uint64 `json:"version"`
+}
+
+// MarshalJSON implements custom JSON marshaller.
+// Messages that are valid UTF-8 are stored as text, otherwise they are stored as hex.
+func (m *signedMessage) MarshalJSON() ([]byte, error) {
+	data := &signedMessageJSON{
+		PublicKey: fmt.Sprintf("%#x", m.PublicKey),
+		Domain:    fmt.Sprintf("%#x", m.Domain),
+		Signature: fmt.Sprintf("%#x", m.Signature),
+		Version:   1,
+	}
+	if utf8.Valid(m.Message) {
+		data.Message = string(m.Message)
+		data.Encoding = "utf8"
+	} else {
+		data.Message = fmt.Sprintf("%#x", m.Message)
+		data.Encoding = "hex"
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaller.
+func (m *signedMessage) UnmarshalJSON(input []byte) error {
+	var data signedMessageJSON
+	if err := json.Unmarshal(input, &data); err != nil {
+		return err
+	}
+	if data.Version != 1 {
+		return fmt.Errorf("unsupported version %d", data.Version)
+	}
+
+	var err error
+	switch data.Encoding {
+	case "utf8":
+		m.Message = []byte(data.Message)
+	case "hex":
+		m.Message, err = hex.DecodeString(strings.TrimPrefix(data.Message, "0x"))
+		if err != nil {
+			return errors.Wrap(err, "message invalid")
+		}
+	default:
+		return fmt.Errorf("unsupported encoding %q", data.Encoding)
+	}
+	if data.PublicKey == "" {
+		return errors.New("public key missing")
+	}
+	m.PublicKey, err = hex.DecodeString(strings.TrimPrefix(data.PublicKey, "0x"))
+	if err != nil {
+		return errors.Wrap(err, "public key invalid")
+	}
+	if data.Domain == "" {
+		return errors.New("domain missing")
+	}
+	m.Domain, err = hex.DecodeString(strings.TrimPrefix(data.Domain, "0x"))
+	if err != nil {
+		return errors.Wrap(err, "domain invalid")
+	}
+	if !bytes.Equal(m.Domain, messageDomain()) {
+		return errors.New("domain is not the message signing domain")
+	}
+	if data.Signature == "" {
+		return errors.New("signature missing")
+	}
+	m.Signature, err = hex.DecodeString(strings.TrimPrefix(data.Signature, "0x"))
+	if err != nil {
+		return errors.Wrap(err, "signature invalid")
+	}
+	return nil
+}
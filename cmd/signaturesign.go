@@ -15,29 +15,62 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
 	"github.com/wealdtech/go-bytesutil"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
+var signatureSignType string
+var signatureSignForkVersion string
+var signatureSignGenesisValidatorsRoot string
+var signatureSignMessage string
+var signatureSignMessageFile string
+var signatureSignThresholdAccounts []string
+
 // signatureSignCmd represents the signature sign command
 var signatureSignCmd = &cobra.Command{
 	Use:   "sign",
-	Short: "Sign a 32-byte piece of data",
+	Short: "Sign a 32-byte piece of data or a beacon object",
 	Long: `Sign presented data.  For example:
 
     ethdo signature sign --data=0x5f24e819400c6a8ee2bfc014343cd971b7eb707320025a7bcd83e621e26c35b7 --account="Personal wallet/Operations" --passphrase="my account passphrase"
 
+Beacon objects can be signed by supplying their type and JSON representation, in which case the domain is calculated for the object.  For example:
+
+    ethdo signature sign --type=voluntary_exit --data='{"epoch":1024,"validator_index":5}' --account="Validators/1" --passphrase="my account passphrase"
+
+Messages of any length can be signed with --message, or with --messagefile to read the message from a file ("-" for stdin), in which case a signed message document is output.  For example:
+
+    ethdo signature sign --message="I control this key" --account="Validators/1" --passphrase="my account passphrase"
+
+If the shares of a distributed account are held locally, a composite signature can be created by signing with enough of them; signing with a single share using --account gives a signature for that share's own public key.  For example:
+
+    ethdo signature sign --type=voluntary_exit --data='{"epoch":1024,"validator_index":5}' --threshold-accounts="Wallet 1/Validator 1,Wallet 3/Validator 1" --passphrase="my account passphrase"
+
 In quiet mode this will return 0 if the data can be signed, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
 		defer cancel()
 
+		if signatureSignMessage != "" || signatureSignMessageFile != "" {
+			signatureSignMessageData(ctx, cmd)
+		}
+
 		assert(viper.GetString("signature-data") != "", "--data is required")
+
+		if signatureSignType != "" {
+			signatureSignObject(ctx, cmd)
+		}
+
+		assert(signatureSignForkVersion == "", "--forkversion is only allowed with --type")
+		assert(signatureSignGenesisValidatorsRoot == "", "--genesisvalidatorsroot is only allowed with --type")
 		data, err := bytesutil.FromHexString(viper.GetString("signature-data"))
 		errCheck(err, "Failed to parse data")
 		assert(len(data) == 32, "data to sign must be 32 bytes")
@@ -50,21 +83,120 @@ In quiet mode this will return 0 if the data can be signed, otherwise 1.`,
 		}
 		outputIf(debug, fmt.Sprintf("Domain is %#x", domain))
 
-		assert(viper.GetString("account") != "", "--account is required")
-		_, account, err := walletAndAccountFromInput(ctx)
-		errCheck(err, "Failed to obtain account")
-
 		var fixedSizeData [32]byte
 		copy(fixedSizeData[:], data)
-		signature, err := signRoot(account, fixedSizeData, domain)
-		errCheck(err, "Failed to sign")
+		var signature e2types.Signature
+		if len(signatureSignThresholdAccounts) > 0 {
+			signature, _ = signatureSignThreshold(ctx, fixedSizeData, domain)
+		} else {
+			assert(viper.GetString("account") != "", "--account is required")
+			_, account, err := walletAndAccountFromInput(ctx)
+			errCheck(err, "Failed to obtain account")
+			signature, err = signRoot(account, fixedSizeData, domain)
+			errCheck(err, "Failed to sign")
+		}
 
 		outputIf(!quiet, fmt.Sprintf("%#x", signature.Marshal()))
 		os.Exit(_exitSuccess)
 	},
 }
 
+// signatureSignObject signs a typed beacon object.
+func signatureSignObject(ctx context.Context, cmd *cobra.Command) {
+	assert(!cmd.Flags().Changed("domain"), "--domain is not allowed with --type; the domain is calculated for the object")
+
+	data, err := obtainJSONInput(viper.GetString("signature-data"))
+	errCheck(err, "Failed to obtain data")
+	obj, err := util.BeaconObjectFromJSON(signatureSignType, data)
+	errCheck(err, fmt.Sprintf("Failed to parse %s", signatureSignType))
+
+	domain, err := beaconDomain(obj.DomainType, signatureSignForkVersion, signatureSignGenesisValidatorsRoot)
+	errCheck(err, "Failed to calculate domain")
+	outputIf(debug, fmt.Sprintf("Domain is %#x", domain))
+
+	var signature e2types.Signature
+	if len(signatureSignThresholdAccounts) > 0 {
+		root, err := obj.Root()
+		errCheck(err, "Failed to calculate object root")
+		signature, _ = signatureSignThreshold(ctx, root, domain)
+	} else {
+		assert(viper.GetString("account") != "", "--account is required")
+		_, account, err := walletAndAccountFromInput(ctx)
+		errCheck(err, "Failed to obtain account")
+		signature, err = signBeaconObject(account, obj, domain)
+		errCheck(err, "Failed to sign")
+	}
+
+	outputIf(!quiet, fmt.Sprintf("%#x", signature.Marshal()))
+	os.Exit(_exitSuccess)
+}
+
+// signatureSignMessageData signs a message of arbitrary length, outputting a signed message document.
+func signatureSignMessageData(ctx context.Context, cmd *cobra.Command) {
+	assert(!cmd.Flags().Changed("domain"), "--domain is not allowed with --message or --messagefile")
+	assert(signatureSignMessage == "" || signatureSignMessageFile == "", "only one of --message and --messagefile is allowed")
+	assert(viper.GetString("signature-data") == "", "--data is not allowed with --message or --messagefile")
+	assert(signatureSignType == "", "--type is not allowed with --message or --messagefile")
+
+	message := []byte(signatureSignMessage)
+	if signatureSignMessageFile != "" {
+		var err error
+		message, err = readMessage(signatureSignMessageFile)
+		errCheck(err, "Failed to read message")
+	}
+	assert(len(message) > 0, "Message is empty")
+
+	domain := messageDomain()
+	outputIf(debug, fmt.Sprintf("Domain is %#x", domain))
+	var signature e2types.Signature
+	var pubKey e2types.PublicKey
+	if len(signatureSignThresholdAccounts) > 0 {
+		signature, pubKey = signatureSignThreshold(ctx, messageRoot(message), domain)
+	} else {
+		assert(viper.GetString("account") != "", "--account is required")
+		_, account, err := walletAndAccountFromInput(ctx)
+		errCheck(err, "Failed to obtain account")
+		pubKey, err = signingPublicKey(account)
+		errCheck(err, "Failed to obtain public key")
+		signature, err = signRoot(account, messageRoot(message), domain)
+		errCheck(err, "Failed to sign")
+	}
+
+	res, err := json.Marshal(&signedMessage{
+		Message:   message,
+		PublicKey: pubKey.Marshal(),
+		Domain:    domain,
+		Signature: signature.Marshal(),
+	})
+	errCheck(err, "Failed to generate JSON")
+	outputIf(!quiet, string(res))
+	os.Exit(_exitSuccess)
+}
+
+// signatureSignThreshold signs a root with the shares of a distributed account supplied in --threshold-accounts,
+// returning the composite signature and public key.
+func signatureSignThreshold(ctx context.Context, root [32]byte, domain []byte) (e2types.Signature, e2types.PublicKey) {
+	assert(viper.GetString("account") == "", "--account is not allowed with --threshold-accounts")
+	assert(viper.GetString("remote") == "", "--threshold-accounts not available with remote wallets")
+	accounts := make([]e2wtypes.Account, len(signatureSignThresholdAccounts))
+	for i := range signatureSignThresholdAccounts {
+		var err error
+		_, accounts[i], err = walletAndAccountFromPath(ctx, signatureSignThresholdAccounts[i])
+		errCheck(err, fmt.Sprintf("Failed to obtain account %q", signatureSignThresholdAccounts[i]))
+	}
+	signature, pubKey, err := thresholdSignRoot(accounts, root, domain)
+	errCheck(err, "Failed to create threshold signature")
+	outputIf(verbose, fmt.Sprintf("Composite public key is %#x", pubKey.Marshal()))
+	return signature, pubKey
+}
+
 func init() {
 	signatureCmd.AddCommand(signatureSignCmd)
 	signatureFlags(signatureSignCmd)
+	signatureSignCmd.Flags().StringVar(&signatureSignType, "type", "", "Type of beacon object supplied in --data (aggregate_and_proof, attestation_data, beacon_block, deposit_message, randao or voluntary_exit)")
+	signatureSignCmd.Flags().StringVar(&signatureSignForkVersion, "forkversion", "", "Use a hard-coded fork version when signing a beacon object (default is to fetch it from the node)")
+	signatureSignCmd.Flags().StringVar(&signatureSignGenesisValidatorsRoot, "genesisvalidatorsroot", "", "Use a hard-coded genesis validators root when signing a beacon object (default is to fetch it from the node)")
+	signatureSignCmd.Flags().StringVar(&signatureSignMessage, "message", "", "Message to sign, of any length")
+	signatureSignCmd.Flags().StringVar(&signatureSignMessageFile, "messagefile", "", "File containing the message to sign (\"-\" for stdin)")
+	signatureSignCmd.Flags().StringSliceVar(&signatureSignThresholdAccounts, "threshold-accounts", nil, "Comma-separated shares of a distributed account with which to create a threshold signature")
 }
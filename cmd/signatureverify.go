@@ -14,9 +14,12 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 
@@ -31,6 +34,8 @@ import (
 
 var signatureVerifySignature string
 var signatureVerifySigner string
+var signatureVerifySignedMessage string
+var signatureVerifySigners []string
 
 // signatureVerifyCmd represents the signature verify command
 var signatureVerifyCmd = &cobra.Command{
@@ -40,8 +45,23 @@ var signatureVerifyCmd = &cobra.Command{
 
     ethdo signature verify --data=0x5f24e819400c6a8ee2bfc014343cd971b7eb707320025a7bcd83e621e26c35b7 --signature=0x8888... --account="Personal wallet/Operations"
 
+Aggregate signatures can be verified by supplying --signers once for each signer, either as a public key or as an account.  If all signers signed the same data it is supplied with --data, otherwise each signer is supplied with its data in the format "signer:data".  For example:
+
+    ethdo signature verify --data=0x5f24e819400c6a8ee2bfc014343cd971b7eb707320025a7bcd83e621e26c35b7 --signature=0x8888... --signers="Validators/1" --signers=0xa99a...
+
+Signed message documents generated by "ethdo signature sign --message" can be verified with --signedmessage.  For example:
+
+    ethdo signature verify --signedmessage=signedmessage.json
+
 In quiet mode this will return 0 if the data can be signed, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if signatureVerifySignedMessage != "" {
+			signatureVerifyMessage()
+		}
+		if len(signatureVerifySigners) > 0 {
+			signatureVerifyAggregate()
+		}
+
 		assert(viper.GetString("signature-data") != "", "--data is required")
 		data, err := bytesutil.FromHexString(viper.GetString("signature-data"))
 		errCheck(err, "Failed to parse data")
@@ -75,6 +95,115 @@ In quiet mode this will return 0 if the data can be signed, otherwise 1.`,
 	},
 }
 
+// signatureVerifyMessage verifies a signed message document.
+func signatureVerifyMessage() {
+	assert(viper.GetString("signature-data") == "", "--data is not allowed with --signedmessage")
+	assert(signatureVerifySignature == "", "--signature is not allowed with --signedmessage")
+
+	var input []byte
+	var err error
+	if signatureVerifySignedMessage == "-" {
+		input, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		input, err = obtainJSONInput(signatureVerifySignedMessage)
+	}
+	errCheck(err, "Failed to obtain signed message")
+	message := &signedMessage{}
+	errCheck(json.Unmarshal(input, message), "Invalid signed message")
+
+	signature, err := e2types.BLSSignatureFromBytes(message.Signature)
+	errCheck(err, "Invalid signature")
+
+	if viper.GetString("account") != "" || signatureVerifySigner != "" {
+		// Ensure that the message was signed by the expected signer, choosing the key the same way as the signer.
+		signerAccount, err := signatureVerifyAccount()
+		errCheck(err, "Failed to obtain account")
+		pubKey, err := signingPublicKey(signerAccount)
+		errCheck(err, "Failed to obtain public key")
+		assert(bytes.Equal(pubKey.Marshal(), message.PublicKey), "Message was not signed by the expected signer")
+	}
+	account, err := util.NewScratchAccount(nil, message.PublicKey)
+	errCheck(err, "Invalid public key")
+	outputIf(debug, fmt.Sprintf("Public key is %#x", message.PublicKey))
+
+	verified, err := verifyRoot(account, messageRoot(message.Message), message.Domain, signature)
+	errCheck(err, "Failed to verify message")
+	assert(verified, "Failed to verify")
+
+	outputIf(verbose, "Verified")
+	os.Exit(_exitSuccess)
+}
+
+// signatureVerifyAggregate verifies an aggregate signature from multiple signers.
+func signatureVerifyAggregate() {
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+	defer cancel()
+
+	assert(viper.GetString("account") == "", "--account is not allowed with --signers")
+	assert(signatureVerifySigner == "", "--signer is not allowed with --signers")
+	assert(signatureVerifySignature != "", "--signature is required")
+	signature, err := bytesutil.FromHexString(signatureVerifySignature)
+	errCheck(err, "Failed to parse signature")
+
+	domain := e2types.Domain(e2types.DomainType([4]byte{0, 0, 0, 0}), e2types.ZeroForkVersion, e2types.ZeroGenesisValidatorsRoot)
+	if viper.GetString("signature-domain") != "" {
+		domain, err = bytesutil.FromHexString(viper.GetString("signature-domain"))
+		errCheck(err, "Failed to parse domain")
+		assert(len(domain) == 32, "Domain data invalid")
+	}
+
+	// Signers are either all "signer" or all "signer:data".
+	pubKeys := make([][]byte, len(signatureVerifySigners))
+	signingRoots := make([][]byte, len(signatureVerifySigners))
+	perSignerData := false
+	for i, signer := range signatureVerifySigners {
+		var data []byte
+		if index := strings.LastIndex(signer, ":0x"); index != -1 {
+			data, err = bytesutil.FromHexString(signer[index+1:])
+			errCheck(err, fmt.Sprintf("Failed to parse data for signer %s", signer))
+			assert(len(data) == 32, "data to verify must be 32 bytes")
+			signer = signer[:index]
+			assert(i == 0 || perSignerData, "Either all or none of the signers must supply data")
+			perSignerData = true
+		} else {
+			assert(!perSignerData, "Either all or none of the signers must supply data")
+		}
+		pubKeys[i], err = signerPublicKey(ctx, signer)
+		errCheck(err, "Failed to obtain signer")
+		outputIf(debug, fmt.Sprintf("Public key %d is %#x", i, pubKeys[i]))
+
+		if perSignerData {
+			var root [32]byte
+			copy(root[:], data)
+			signingRoot, err := calculateSigningRoot(root, domain)
+			errCheck(err, "Failed to calculate signing root")
+			signingRoots[i] = signingRoot[:]
+		}
+	}
+
+	var verified bool
+	if perSignerData {
+		assert(viper.GetString("signature-data") == "", "--data is not allowed when signers supply their own data")
+		verified, err = util.AggregateVerify(signature, pubKeys, signingRoots)
+		errCheck(err, "Failed to verify data")
+	} else {
+		assert(viper.GetString("signature-data") != "", "--data is required")
+		data, err := bytesutil.FromHexString(viper.GetString("signature-data"))
+		errCheck(err, "Failed to parse data")
+		assert(len(data) == 32, "data to verify must be 32 bytes")
+		var root [32]byte
+		copy(root[:], data)
+		signingRoot, err := calculateSigningRoot(root, domain)
+		errCheck(err, "Failed to calculate signing root")
+		verified, err = util.FastAggregateVerify(signature, pubKeys, signingRoot[:])
+		errCheck(err, "Failed to verify data")
+	}
+	assert(verified, "Failed to verify")
+
+	outputIf(verbose, "Verified")
+	os.Exit(_exitSuccess)
+}
+
 // signatureVerifyAccount obtains the account for the signature verify command.
 func signatureVerifyAccount() (e2wtypes.Account, error) {
 	var account e2wtypes.Account
@@ -103,4 +232,6 @@ func init() {
 	signatureFlags(signatureVerifyCmd)
 	signatureVerifyCmd.Flags().StringVar(&signatureVerifySignature, "signature", "", "the signature to verify")
 	signatureVerifyCmd.Flags().StringVar(&signatureVerifySigner, "signer", "", "the public key of the signer (only if --account is not supplied)")
+	signatureVerifyCmd.Flags().StringArrayVar(&signatureVerifySigners, "signers", nil, "a signer of an aggregate signature, as a public key or account, optionally with its data as \"signer:data\" (supply once for each signer)")
+	signatureVerifyCmd.Flags().StringVar(&signatureVerifySignedMessage, "signedmessage", "", "a signed message document to verify, or path to the document (\"-\" for stdin)")
 }
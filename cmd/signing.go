@@ -14,12 +14,18 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/prysmaticlabs/go-ssz"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/grpc"
+	"github.com/wealdtech/ethdo/util"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 	wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
@@ -61,21 +67,116 @@ func signRoot(account wtypes.Account, root [32]byte, domain []byte) (e2types.Sig
 		return signGeneric(account, root[:], domain)
 	}
 
-	// Build the signing data manually.
-	container := &signingContainer{
-		Root:   root[:],
-		Domain: domain,
-	}
-	outputIf(debug, fmt.Sprintf("Signing container:\n root: %#x\n domain: %#x", container.Root, container.Domain))
-	signingRoot, err := ssz.HashTreeRoot(container)
+	signingRoot, err := calculateSigningRoot(root, domain)
 	if err != nil {
 		return nil, err
 	}
-	outputIf(debug, fmt.Sprintf("Signing root: %#x", signingRoot))
 	return sign(account, signingRoot[:])
 }
 
 func verifyRoot(account wtypes.Account, root [32]byte, domain []byte, signature e2types.Signature) (bool, error) {
+	signingRoot, err := calculateSigningRoot(root, domain)
+	if err != nil {
+		return false, err
+	}
+	return verify(account, signingRoot[:], signature)
+}
+
+// signingPublicKey returns the public key against which signatures from the account verify.  A local distributed
+// account signs with its share alone, so this is the share's public key rather than the composite public key.
+func signingPublicKey(account wtypes.Account) (e2types.PublicKey, error) {
+	if _, isDistributed := account.(e2wtypes.AccountCompositePublicKeyProvider); isDistributed && !remote {
+		pubKeyProvider, isPubKeyProvider := account.(e2wtypes.AccountPublicKeyProvider)
+		if !isPubKeyProvider {
+			return nil, errors.New("account does not provide the public key of its share")
+		}
+		return pubKeyProvider.PublicKey(), nil
+	}
+	return bestPublicKey(account)
+}
+
+// thresholdSignRoot signs a root with each of the supplied shares of a distributed account, and recovers the
+// composite signature from them.  The composite signature is verified against the composite public key before
+// being returned.
+func thresholdSignRoot(accounts []wtypes.Account, root [32]byte, domain []byte) (e2types.Signature, e2types.PublicKey, error) {
+	var compositePubKey e2types.PublicKey
+	var threshold uint32
+	var verificationVector [][]byte
+	signatures := make(map[uint64][]byte)
+	for _, account := range accounts {
+		distributedAccount, isDistributedAccount := account.(e2wtypes.DistributedAccount)
+		if !isDistributedAccount {
+			return nil, nil, fmt.Errorf("account %q is not a distributed account", account.Name())
+		}
+		verificationVectorProvider, isVerificationVectorProvider := account.(e2wtypes.AccountVerificationVectorProvider)
+		if !isVerificationVectorProvider {
+			return nil, nil, fmt.Errorf("account %q does not provide a verification vector", account.Name())
+		}
+		pubKeyProvider, isPubKeyProvider := account.(e2wtypes.AccountPublicKeyProvider)
+		if !isPubKeyProvider {
+			return nil, nil, fmt.Errorf("account %q does not provide a public key", account.Name())
+		}
+		if compositePubKey == nil {
+			compositePubKey = distributedAccount.CompositePublicKey()
+			threshold = distributedAccount.SigningThreshold()
+			for _, pubKey := range verificationVectorProvider.VerificationVector() {
+				verificationVector = append(verificationVector, pubKey.Marshal())
+			}
+		}
+		if !bytes.Equal(distributedAccount.CompositePublicKey().Marshal(), compositePubKey.Marshal()) {
+			return nil, nil, fmt.Errorf("account %q is a share of a different key", account.Name())
+		}
+
+		// Find the participant ID of the share from its public key.
+		id := uint64(0)
+		for participantID := range distributedAccount.Participants() {
+			pubKey, err := util.ThresholdSharePublicKey(verificationVector, participantID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if bytes.Equal(pubKey, pubKeyProvider.PublicKey().Marshal()) {
+				id = participantID
+				break
+			}
+		}
+		if id == 0 {
+			return nil, nil, fmt.Errorf("failed to find participant ID for account %q", account.Name())
+		}
+		if _, exists := signatures[id]; exists {
+			return nil, nil, fmt.Errorf("share %d supplied more than once", id)
+		}
+
+		signature, err := signRoot(account, root, domain)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("failed to sign with account %q", account.Name()))
+		}
+		outputIf(debug, fmt.Sprintf("Signature for share %d is %#x", id, signature.Marshal()))
+		signatures[id] = signature.Marshal()
+	}
+	if len(signatures) < int(threshold) {
+		return nil, nil, fmt.Errorf("at least %d shares are required to sign", threshold)
+	}
+
+	compositeSig, err := util.RecoverThresholdSignature(signatures)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := e2types.BLSSignatureFromBytes(compositeSig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid composite signature")
+	}
+	signingRoot, err := calculateSigningRoot(root, domain)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !signature.Verify(signingRoot[:], compositePubKey) {
+		return nil, nil, errors.New("composite signature does not verify against the composite public key")
+	}
+	return signature, compositePubKey, nil
+}
+
+// calculateSigningRoot calculates the signing root for a root and domain.
+func calculateSigningRoot(root [32]byte, domain []byte) ([32]byte, error) {
 	// Build the signing data manually.
 	container := &signingContainer{
 		Root:   root[:],
@@ -84,10 +185,66 @@ func verifyRoot(account wtypes.Account, root [32]byte, domain []byte, signature
 	outputIf(debug, fmt.Sprintf("Signing container:\n root: %#x\n domain: %#x", container.Root, container.Domain))
 	signingRoot, err := ssz.HashTreeRoot(container)
 	if err != nil {
-		return false, err
+		return [32]byte{}, err
 	}
 	outputIf(debug, fmt.Sprintf("Signing root: %#x", signingRoot))
-	return verify(account, signingRoot[:], signature)
+	return signingRoot, nil
+}
+
+// beaconDomain calculates the domain for the given domain type.  The fork version and genesis validators root
+// are taken from the supplied hex strings if present, otherwise they are obtained from the beacon node.
+func beaconDomain(domainType e2types.DomainType, forkVersionInput string, genesisValidatorsRootInput string) ([]byte, error) {
+	var forkVersion []byte
+	var err error
+	if forkVersionInput != "" {
+		forkVersion, err = hex.DecodeString(strings.TrimPrefix(forkVersionInput, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to decode fork version %s", forkVersionInput))
+		}
+		if len(forkVersion) != 4 {
+			return nil, errors.New("fork version must be exactly four bytes")
+		}
+	} else {
+		if err := connect(); err != nil {
+			return nil, errors.Wrap(err, "failed to connect to beacon node")
+		}
+		config, err := grpc.FetchChainConfig(eth2GRPCConn)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain beacon chain configuration")
+		}
+		genesisForkVersion, exists := config["GenesisForkVersion"]
+		if !exists {
+			return nil, errors.New("failed to obtain genesis fork version")
+		}
+		forkVersion = genesisForkVersion.([]byte)
+	}
+	outputIf(debug, fmt.Sprintf("Fork version is %#x", forkVersion))
+
+	var genesisValidatorsRoot []byte
+	switch {
+	case domainType == e2types.DomainDeposit:
+		// Deposits are valid across forks, so do not use the genesis validators root.
+		genesisValidatorsRoot = e2types.ZeroGenesisValidatorsRoot
+	case genesisValidatorsRootInput != "":
+		genesisValidatorsRoot, err = hex.DecodeString(strings.TrimPrefix(genesisValidatorsRootInput, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to decode genesis validators root %s", genesisValidatorsRootInput))
+		}
+		if len(genesisValidatorsRoot) != 32 {
+			return nil, errors.New("genesis validators root must be exactly 32 bytes")
+		}
+	default:
+		if err := connect(); err != nil {
+			return nil, errors.Wrap(err, "failed to connect to beacon node")
+		}
+		genesisValidatorsRoot, err = grpc.FetchGenesisValidatorsRoot(eth2GRPCConn)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain genesis validators root")
+		}
+	}
+	outputIf(debug, fmt.Sprintf("Genesis validators root is %#x", genesisValidatorsRoot))
+
+	return e2types.Domain(domainType, forkVersion, genesisValidatorsRoot), nil
 }
 
 func signGeneric(account wtypes.Account, data []byte, domain []byte) (e2types.Signature, error) {
@@ -114,6 +271,50 @@ func signGeneric(account wtypes.Account, data []byte, domain []byte) (e2types.Si
 	return signature, err
 }
 
+// signBeaconObject signs a beacon object.  Protecting signers are passed the object's
+// fields where they provide a typed signing method for it, so that they can apply their protection.
+func signBeaconObject(account wtypes.Account, obj *util.BeaconObject, domain []byte) (e2types.Signature, error) {
+	protectingSigner, isProtectingSigner := account.(e2wtypes.AccountProtectingSigner)
+	if !isProtectingSigner {
+		root, err := obj.Root()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate object root")
+		}
+		return signRoot(account, root, domain)
+	}
+
+	alreadyUnlocked, err := unlock(account)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+	defer cancel()
+
+	var signature e2types.Signature
+	switch o := obj.Object.(type) {
+	case *ethpb.BeaconBlockHeader:
+		outputIf(debug, "Signing as beacon proposal")
+		signature, err = protectingSigner.SignBeaconProposal(ctx, o.Slot, o.ProposerIndex, o.ParentRoot, o.StateRoot, o.BodyRoot, domain)
+	case *ethpb.AttestationData:
+		outputIf(debug, "Signing as beacon attestation")
+		signature, err = protectingSigner.SignBeaconAttestation(ctx, o.Slot, o.CommitteeIndex, o.BeaconBlockRoot, o.Source.Epoch, o.Source.Root, o.Target.Epoch, o.Target.Root, domain)
+	default:
+		var root [32]byte
+		root, err = obj.Root()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate object root")
+		}
+		outputIf(debug, fmt.Sprintf("Signing %x as generic data", root))
+		signature, err = protectingSigner.SignGeneric(ctx, root[:], domain)
+	}
+	if !alreadyUnlocked {
+		if err := lock(account); err != nil {
+			return nil, errors.Wrap(err, "failed to lock account")
+		}
+	}
+	return signature, err
+}
+
 // sign signs arbitrary data, handling unlocking and locking as required.
 func sign(account wtypes.Account, data []byte) (e2types.Signature, error) {
 	alreadyUnlocked, err := unlock(account)
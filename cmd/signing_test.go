@@ -0,0 +1,112 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/wealdtech/ethdo/util"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	distributed "github.com/wealdtech/go-eth2-wallet-distributed"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	filesystem "github.com/wealdtech/go-eth2-wallet-store-filesystem"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestSignVerifyLocalDistributedAccount(t *testing.T) {
+	if err := e2types.InitBLS(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	base, err := ioutil.TempDir("", "ethdo-signing-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+
+	wallet, err := distributed.CreateWallet(ctx, "Test", filesystem.New(filesystem.WithLocation(base)), keystorev4.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	shares, verificationVector, err := util.DistributedKeyGeneration(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	participants := map[uint64]string{
+		1: "signer-1:8881",
+		2: "signer-2:8881",
+		3: "signer-3:8881",
+	}
+	account, err := wallet.(e2wtypes.WalletDistributedAccountImporter).ImportDistributedAccount(ctx, "Account", shares[1], 2, verificationVector, participants, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := account.(e2wtypes.AccountLocker).Unlock(ctx, []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	root := messageRoot([]byte("Test message"))
+	domain := e2types.Domain(e2types.DomainType([4]byte{0, 0, 0, 0}), e2types.ZeroForkVersion, e2types.ZeroGenesisValidatorsRoot)
+	signature, err := signRoot(account, root, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signing public key is that of the share, not the composite public key.
+	pubKey, err := signingPublicKey(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharePubKey, err := util.ThresholdSharePublicKey(verificationVector, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pubKey.Marshal(), sharePubKey) {
+		t.Fatalf("signing public key %#x does not match share public key %#x", pubKey.Marshal(), sharePubKey)
+	}
+
+	// The signature verifies against the signing public key...
+	signer, err := util.NewScratchAccount(nil, pubKey.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := verifyRoot(signer, root, domain, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verified {
+		t.Fatal("signature from local distributed account did not verify against signing public key")
+	}
+
+	// ...but not against the composite public key.
+	composite, err := util.NewScratchAccount(nil, verificationVector[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err = verifyRoot(composite, root, domain, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified {
+		t.Fatal("share signature unexpectedly verified against composite public key")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethdo/util"
+	"github.com/wealdtech/go-bytesutil"
+)
+
+var sszRootType string
+var sszRootData string
+var sszRootDomainType string
+var sszRootForkVersion string
+var sszRootGenesisValidatorsRoot string
+
+var sszRootCmd = &cobra.Command{
+	Use:   "root",
+	Short: "Calculate the root and signing root of an object",
+	Long: `Calculate the hash tree root of an object, along with its domain and signing root.  For example:
+
+    ethdo ssz root --type=voluntary_exit --data='{"epoch":1024,"validator_index":5}' --forkversion=0x00000001 --genesisvalidatorsroot=0x04700007fabc8282644aed6d1c7c9e21d38a03a0c4ba193f3afe428824b3a673
+
+Supported types are aggregate_and_proof, attestation_data, beacon_block, deposit_message, randao and voluntary_exit.
+If the fork version or genesis validators root are not supplied they will be obtained from the beacon node.
+
+In quiet mode this will return 0 if the roots can be calculated, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(sszRootType != "", "--type is required")
+		assert(sszRootData != "", "--data is required")
+		data, err := obtainJSONInput(sszRootData)
+		errCheck(err, "Failed to obtain data")
+
+		obj, err := util.BeaconObjectFromJSON(sszRootType, data)
+		errCheck(err, fmt.Sprintf("Failed to parse %s", sszRootType))
+		root, err := obj.Root()
+		errCheck(err, "Failed to calculate object root")
+
+		domainType := obj.DomainType
+		if sszRootDomainType != "" {
+			domainTypeBytes, err := bytesutil.FromHexString(sszRootDomainType)
+			errCheck(err, "Failed to parse domain type")
+			assert(len(domainTypeBytes) == 4, "Domain type must be exactly four bytes")
+			copy(domainType[:], domainTypeBytes)
+		}
+		domain, err := beaconDomain(domainType, sszRootForkVersion, sszRootGenesisValidatorsRoot)
+		errCheck(err, "Failed to calculate domain")
+
+		signingRoot, err := calculateSigningRoot(root, domain)
+		errCheck(err, "Failed to calculate signing root")
+
+		if quiet {
+			os.Exit(_exitSuccess)
+		}
+		fmt.Printf("Object root: %#x\n", root)
+		outputIf(verbose, fmt.Sprintf("Domain type: %#x", domainType))
+		fmt.Printf("Domain: %#x\n", domain)
+		fmt.Printf("Signing root: %#x\n", signingRoot)
+		os.Exit(_exitSuccess)
+	},
+}
+
+// obtainJSONInput obtains JSON from an input, which could be JSON itself or a path to JSON.
+func obtainJSONInput(input string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(input), "{") {
+		// Looks like JSON.
+		return []byte(input), nil
+	}
+	// Assume it's a path to JSON.
+	data, err := ioutil.ReadFile(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read JSON file")
+	}
+	return data, nil
+}
+
+func init() {
+	sszCmd.AddCommand(sszRootCmd)
+	sszFlags(sszRootCmd)
+	sszRootCmd.Flags().StringVar(&sszRootType, "type", "", "Type of the object (aggregate_and_proof, attestation_data, beacon_block, deposit_message, randao or voluntary_exit)")
+	sszRootCmd.Flags().StringVar(&sszRootData, "data", "", "JSON data, or path to JSON data")
+	sszRootCmd.Flags().StringVar(&sszRootDomainType, "domaintype", "", "Override the domain type for the object (default is the domain type for the object's type)")
+	sszRootCmd.Flags().StringVar(&sszRootForkVersion, "forkversion", "", "Use a hard-coded fork version (default is to fetch it from the node)")
+	sszRootCmd.Flags().StringVar(&sszRootGenesisValidatorsRoot, "genesisvalidatorsroot", "", "Use a hard-coded genesis validators root (default is to fetch it from the node)")
+}
@@ -0,0 +1,99 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// storeCmd represents the store command
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Manage wallet stores",
+	Long:  `Manage wallet stores.`,
+}
+
+func init() {
+	RootCmd.AddCommand(storeCmd)
+}
+
+// storeListError returns the error from the most recent listing of a store, for stores that report one.
+func storeListError(store e2wtypes.Store) error {
+	if lister, ok := store.(interface{ ListError() error }); ok {
+		return lister.ListError()
+	}
+	return nil
+}
+
+// storeWalletIDs returns the IDs of all wallets held in a store, including those that cannot be decrypted with the
+// store's passphrase and so are silently skipped when retrieving wallets.
+func storeWalletIDs(store e2wtypes.Store) ([]uuid.UUID, error) {
+	if lister, ok := store.(interface{ WalletIDs() ([]uuid.UUID, error) }); ok {
+		return lister.WalletIDs()
+	}
+	if store.Name() != "filesystem" {
+		return nil, fmt.Errorf("cannot list wallets in %s store", store.Name())
+	}
+	storeLocationProvider, ok := store.(e2wtypes.StoreLocationProvider)
+	if !ok {
+		return nil, errors.New("store does not provide a location")
+	}
+	location := storeLocationProvider.Location()
+	entries, err := ioutil.ReadDir(location)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []uuid.UUID{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to read store")
+	}
+	walletIDs := make([]uuid.UUID, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		walletID, err := uuid.Parse(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Wallet directories hold the wallet header in a file with the same name.
+		if _, err := os.Stat(filepath.Join(location, entry.Name(), entry.Name())); err != nil {
+			continue
+		}
+		walletIDs = append(walletIDs, walletID)
+	}
+	return walletIDs, nil
+}
+
+// removeStoreWallet removes a wallet, along with its accounts and index, from a store.
+func removeStoreWallet(store e2wtypes.Store, walletID uuid.UUID) error {
+	if remover, ok := store.(interface{ RemoveWallet(uuid.UUID) error }); ok {
+		return remover.RemoveWallet(walletID)
+	}
+	if store.Name() != "filesystem" {
+		return fmt.Errorf("cannot remove wallets from %s store", store.Name())
+	}
+	storeLocationProvider, ok := store.(e2wtypes.StoreLocationProvider)
+	if !ok {
+		return errors.New("store does not provide a location")
+	}
+	return os.RemoveAll(filepath.Join(storeLocationProvider.Location(), walletID.String()))
+}
@@ -0,0 +1,148 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var storePassphraseChangeCmd = &cobra.Command{
+	Use:   "change",
+	Short: "Change the passphrase of a store",
+	Long: `Change the passphrase of a store, re-encrypting all of the wallets and accounts it holds.  For example:
+
+    ethdo store passphrase change --storepassphrase="my old secret" --newpassphrase="my new secret"
+
+All wallets in the store, including those in the trash, are read before any are changed.  If any wallet cannot be read with the current passphrase no changes are made, and if any change fails those already made are rolled back.
+
+In quiet mode this will return 0 if the passphrase has been changed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(!remote, "store passphrase change not available with remote wallets")
+		assert(getNewPassphrase() != "", "--newpassphrase is required")
+		assert(getNewPassphrase() != getStorePassphrase(), "--newpassphrase is the same as the current passphrase")
+
+		updatedStore, err := newStore(rootStore, viper.GetString("base-dir"), getNewPassphrase())
+		errCheck(err, "Failed to access store with new passphrase")
+
+		// Obtain all records before storing any, so that a failure to read does not leave a partial change.
+		steps, wallets, err := storeChangeSteps(store, updatedStore)
+		errCheck(err, "Failed to read store")
+		if store.Name() == "filesystem" {
+			location, err := defaultStoreLocation()
+			errCheck(err, "Failed to obtain store location")
+			tombstones, err := walletTombstones(location)
+			errCheck(err, "Failed to obtain deleted wallets")
+			for _, tombstone := range tombstones {
+				// Each trash entry is laid out as a store holding the single deleted wallet.
+				trashStore, err := newStore(rootStore, tombstone.Entry, getStorePassphrase())
+				errCheck(err, fmt.Sprintf("Failed to access deleted wallet %s", tombstone.Name))
+				updatedTrashStore, err := newStore(rootStore, tombstone.Entry, getNewPassphrase())
+				errCheck(err, fmt.Sprintf("Failed to access deleted wallet %s with new passphrase", tombstone.Name))
+				trashSteps, trashWallets, err := storeChangeSteps(trashStore, updatedTrashStore)
+				errCheck(err, fmt.Sprintf("Failed to read deleted wallet %s", tombstone.Name))
+				steps = append(steps, trashSteps...)
+				wallets += trashWallets
+			}
+		}
+		assert(wallets > 0, "No wallets found")
+
+		for i := range steps {
+			if err := steps[i].write(steps[i].to); err != nil {
+				// The records are held unencrypted, so writing them to the original store rolls back the change.
+				for j := i; j >= 0; j-- {
+					if rollbackErr := steps[j].write(steps[j].from); rollbackErr != nil {
+						outputIf(!quiet, fmt.Sprintf("Failed to roll back: %v", rollbackErr))
+					}
+				}
+				die(fmt.Sprintf("Failed to change store passphrase: %v", err))
+			}
+		}
+		outputIf(verbose, fmt.Sprintf("Changed passphrase for %d wallets", wallets))
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+// storeChangeStep is a single write required to move a store to a new passphrase.
+type storeChangeStep struct {
+	from  e2wtypes.Store
+	to    e2wtypes.Store
+	write func(e2wtypes.Store) error
+}
+
+// storeChangeSteps returns the writes required to copy every wallet in one store to another, along with the number of
+// wallets.  It fails if any wallet cannot be read, rather than skipping it as retrieving the wallets would.
+func storeChangeSteps(from e2wtypes.Store, to e2wtypes.Store) ([]*storeChangeStep, int, error) {
+	walletIDs, err := storeWalletIDs(from)
+	if err != nil {
+		return nil, 0, err
+	}
+	steps := make([]*storeChangeStep, 0)
+	for _, walletID := range walletIDs {
+		data, err := from.RetrieveWalletByID(walletID)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, fmt.Sprintf("wallet %s cannot be read; check --storepassphrase", walletID))
+		}
+		wallet := &storeRecord{}
+		if err := json.Unmarshal(data, wallet); err != nil {
+			return nil, 0, errors.Wrap(err, fmt.Sprintf("failed to read wallet %s", walletID))
+		}
+		writes, err := storeWalletWrites(from, wallet, data)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, fmt.Sprintf("failed to read wallet %s", wallet.Name))
+		}
+		for _, write := range writes {
+			steps = append(steps, &storeChangeStep{from: from, to: to, write: write})
+		}
+	}
+	return steps, len(walletIDs), nil
+}
+
+// storeWalletWrites returns the writes required to store a wallet along with its accounts and index.
+func storeWalletWrites(from e2wtypes.Store, wallet *storeRecord, data []byte) ([]func(e2wtypes.Store) error, error) {
+	writes := []func(e2wtypes.Store) error{
+		func(s e2wtypes.Store) error {
+			return s.StoreWallet(wallet.ID, wallet.Name, data)
+		},
+	}
+	for accountData := range from.RetrieveAccounts(wallet.ID) {
+		account := &storeRecord{}
+		if err := json.Unmarshal(accountData, account); err != nil {
+			return nil, errors.Wrap(err, "failed to read account")
+		}
+		accountData := accountData
+		writes = append(writes, func(s e2wtypes.Store) error {
+			return s.StoreAccount(wallet.ID, account.ID, accountData)
+		})
+	}
+	// Wallets without accounts may not have an index.
+	if index, err := from.RetrieveAccountsIndex(wallet.ID); err == nil {
+		writes = append(writes, func(s e2wtypes.Store) error {
+			return s.StoreAccountsIndex(wallet.ID, index)
+		})
+	}
+	return writes, nil
+}
+
+func init() {
+	storePassphraseCmd.AddCommand(storePassphraseChangeCmd)
+	newPassphraseFlags(storePassphraseChangeCmd)
+}
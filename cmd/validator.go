@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/spf13/cobra"
 )
 
@@ -30,3 +31,9 @@ func init() {
 
 func validatorFlags(cmd *cobra.Command) {
 }
+
+// validatorKnown returns true if the beacon chain knows of a validator with the given status.  This includes validators
+// that have deposited but are not yet pending, as well as those that have exited, as their keys are still required.
+func validatorKnown(status ethpb.ValidatorStatus) bool {
+	return status != ethpb.ValidatorStatus_UNKNOWN_STATUS
+}
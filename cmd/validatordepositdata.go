@@ -44,7 +44,7 @@ var validatorDepositDataCmd = &cobra.Command{
 
     ethdo validator depositdata --validatoraccount=primary/validator --withdrawalaccount=primary/current --value="32 Ether"
 
-If validatoraccount is provided with an account path it will generate deposit data for all matching accounts.
+If validatoraccount is provided with an account path it will generate deposit data for all matching accounts.  Alternatively --select can choose validator accounts in any wallet by their labels, for example --select="label:cohort=2020-q4".
 
 The information generated can be passed to ethereal to create a deposit from the Ethereum 1 chain.
 
@@ -53,14 +53,14 @@ In quiet mode this will return 0 if the the data can be generated correctly, oth
 		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
 		defer cancel()
 
-		assert(validatorDepositDataValidatorAccount != "", "--validatoraccount is required")
-		validatorWallet, validatorAccounts, err := walletAndAccountsFromPath(ctx, validatorDepositDataValidatorAccount)
+		assert(validatorDepositDataValidatorAccount != "" || viper.GetString("select") != "", "--validatoraccount or --select is required")
+		validatorAccounts, err := accountsFromInput(ctx, validatorDepositDataValidatorAccount)
 		errCheck(err, "Failed to obtain validator accounts")
 		assert(len(validatorAccounts) > 0, "Failed to obtain validator account")
 
 		for _, validatorAccount := range validatorAccounts {
-			outputIf(verbose, fmt.Sprintf("Creating deposit for %s/%s", validatorWallet.Name(), validatorAccount.Name()))
-			pubKey, err := bestPublicKey(validatorAccount)
+			outputIf(verbose, fmt.Sprintf("Creating deposit for %s/%s", validatorAccount.wallet.Name(), validatorAccount.account.Name()))
+			pubKey, err := bestPublicKey(validatorAccount.account)
 			errCheck(err, "Validator account does not provide a public key")
 			outputIf(debug, fmt.Sprintf("Validator public key is %#x", pubKey.Marshal()))
 		}
@@ -96,7 +96,9 @@ In quiet mode this will return 0 if the the data can be generated correctly, oth
 
 		// For each key, generate deposit data
 		outputs := make([]string, 0)
-		for _, validatorAccount := range validatorAccounts {
+		for _, selected := range validatorAccounts {
+			validatorWallet := selected.wallet
+			validatorAccount := selected.account
 			validatorPubKey, err := bestPublicKey(validatorAccount)
 			errCheck(err, "Validator account does not provide a public key")
 			depositData := struct {
@@ -220,6 +222,7 @@ In quiet mode this will return 0 if the the data can be generated correctly, oth
 func init() {
 	validatorCmd.AddCommand(validatorDepositDataCmd)
 	validatorFlags(validatorDepositDataCmd)
+	selectFlags(validatorDepositDataCmd)
 	validatorDepositDataCmd.Flags().StringVar(&validatorDepositDataValidatorAccount, "validatoraccount", "", "Account of the account carrying out the validation")
 	validatorDepositDataCmd.Flags().StringVar(&validatorDepositDataWithdrawalAccount, "withdrawalaccount", "", "Account of the account to which the validator funds will be withdrawn")
 	validatorDepositDataCmd.Flags().StringVar(&validatorDepositDataWithdrawalPubKey, "withdrawalpubkey", "", "Public key of the account to which the validator funds will be withdrawn")
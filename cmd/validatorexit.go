@@ -44,6 +44,8 @@ var validatorExitCmd = &cobra.Command{
 
     ethdo validator exit --account=primary/validator --passphrase=secret
 
+Multiple validators can be exited by choosing their accounts with --select, for example --select="label:cohort=2020-q4".
+
 In quiet mode this will return 0 if the transaction has been generated, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
@@ -52,12 +54,30 @@ In quiet mode this will return 0 if the transaction has been generated, otherwis
 		err := connect()
 		errCheck(err, "Failed to obtain connect to Ethereum 2 beacon chain node")
 
+		if viper.GetString("select") != "" {
+			validatorExitSelected(ctx)
+			os.Exit(_exitSuccess)
+		}
+
 		exit, signature, forkVersion := validatorExitHandleInput(ctx)
 		validatorExitHandleExit(ctx, exit, signature, forkVersion)
 		os.Exit(_exitSuccess)
 	},
 }
 
+// validatorExitSelected exits each of the validators chosen with --select.
+func validatorExitSelected(ctx context.Context) {
+	assert(viper.GetString("account") == "" && validatorExitKey == "" && validatorExitJSON == "", "--select cannot be used with --account, --key or --json")
+	accounts, err := selectedAccountsFromInput(ctx)
+	errCheck(err, "Failed to obtain accounts")
+	assert(len(accounts) > 0, "No accounts selected")
+	for _, selected := range accounts {
+		outputIf(verbose, fmt.Sprintf("Exiting %s/%s", selected.wallet.Name(), selected.account.Name()))
+		exit, signature, forkVersion := validatorExitHandleAccountInput(ctx, selected.account)
+		validatorExitHandleExit(ctx, exit, signature, forkVersion)
+	}
+}
+
 func validatorExitHandleInput(ctx context.Context) (*ethpb.VoluntaryExit, e2types.Signature, []byte) {
 	if validatorExitJSON != "" {
 		return validatorExitHandleJSONInput(validatorExitJSON)
@@ -184,6 +204,7 @@ func validatorExitHandleExit(ctx context.Context, exit *ethpb.VoluntaryExit, sig
 func init() {
 	validatorCmd.AddCommand(validatorExitCmd)
 	validatorFlags(validatorExitCmd)
+	selectFlags(validatorExitCmd)
 	validatorExitCmd.Flags().Int64Var(&validatorExitEpoch, "epoch", -1, "Epoch at which to exit (defaults to current epoch)")
 	validatorExitCmd.Flags().StringVar(&validatorExitKey, "key", "", "Private key if account not known by ethdo")
 	validatorExitCmd.Flags().BoolVar(&validatorExitJSONOutput, "json-output", false, "Print JSON transaction; do not broadcast to network")
@@ -0,0 +1,152 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// walletBackupVersion is the version of the backup archive format.
+const walletBackupVersion = 1
+
+// walletBackupPrefix is the prefix of backup archive filenames.
+const walletBackupPrefix = "ethdo-backup-"
+
+// walletBackupArchive is the backup archive.  Each wallet is held as an encrypted wallet export.
+type walletBackupArchive struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Wallets []string  `json:"wallets"`
+}
+
+var walletBackupDir string
+var walletBackupPassphrase string
+var walletBackupRetain int
+
+var walletBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up wallets",
+	Long: `Back up one or all wallets to an encrypted archive in a directory.  For example:
+
+    ethdo wallet backup --wallet=primary --dir=/backups --backuppassphrase="my backup secret"
+
+If --wallet is not supplied all wallets are backed up.  Each archive is named with the time of the backup, so this command can be run on a schedule; --retain limits the number of archives kept in the directory.
+
+In quiet mode this will return 0 if the backup is written successfully, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(viper.GetString("remote") == "", "wallet backup not available with remote wallets")
+		assert(walletBackupDir != "", "--dir is required")
+		assert(walletBackupPassphrase != "", "--backuppassphrase is required")
+		assert(walletBackupRetain >= 0, "--retain cannot be negative")
+
+		wallets := make([]e2wtypes.Wallet, 0)
+		if viper.GetString("wallet") != "" {
+			wallet, err := walletFromPath(ctx, viper.GetString("wallet"))
+			errCheck(err, "Failed to access wallet")
+			wallets = append(wallets, wallet)
+		} else {
+			for wallet := range e2wallet.Wallets() {
+				wallets = append(wallets, wallet)
+			}
+			errCheck(storeListError(store), "Failed to list wallets")
+		}
+		assert(len(wallets) > 0, "No wallets to back up")
+
+		archive := &walletBackupArchive{
+			Version: walletBackupVersion,
+			Created: time.Now().UTC(),
+			Wallets: make([]string, 0, len(wallets)),
+		}
+		for _, wallet := range wallets {
+			exporter, ok := wallet.(e2wtypes.WalletExporter)
+			assert(ok, fmt.Sprintf("wallets of type %q do not allow exporting accounts", wallet.Type()))
+			exportData, err := exporter.Export(ctx, []byte(walletBackupPassphrase))
+			errCheck(err, fmt.Sprintf("Failed to export wallet %s", wallet.Name()))
+			archive.Wallets = append(archive.Wallets, fmt.Sprintf("%#x", exportData))
+			outputIf(verbose, fmt.Sprintf("Backed up wallet %s", wallet.Name()))
+		}
+
+		data, err := json.Marshal(archive)
+		errCheck(err, "Failed to create backup")
+		errCheck(os.MkdirAll(walletBackupDir, 0700), "Failed to create backup directory")
+		filename := filepath.Join(walletBackupDir, fmt.Sprintf("%s%s.json", walletBackupPrefix, archive.Created.Format("20060102T150405Z")))
+		_, err = os.Stat(filename)
+		assert(os.IsNotExist(err), fmt.Sprintf("Backup %s already exists", filename))
+		errCheck(ioutil.WriteFile(filename, data, 0600), "Failed to write backup")
+		outputIf(!quiet, filename)
+
+		if walletBackupRetain > 0 {
+			removed, err := walletBackupPrune(walletBackupDir, walletBackupRetain)
+			errCheck(err, "Failed to remove old backups")
+			for _, file := range removed {
+				outputIf(verbose, fmt.Sprintf("Removed old backup %s", file))
+			}
+		}
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+// walletBackupPrune removes all but the most recent retain backup archives in the directory.
+func walletBackupPrune(dir string, retain int) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]string, 0)
+	for _, file := range files {
+		if !file.IsDir() && strings.HasPrefix(file.Name(), walletBackupPrefix) && strings.HasSuffix(file.Name(), ".json") {
+			backups = append(backups, file.Name())
+		}
+	}
+	if len(backups) <= retain {
+		return nil, nil
+	}
+	// Filenames contain the time of the backup, so sort in to time order.
+	sort.Strings(backups)
+	removed := make([]string, 0)
+	for _, backup := range backups[:len(backups)-retain] {
+		filename := filepath.Join(dir, backup)
+		if err := os.Remove(filename); err != nil {
+			return removed, errors.Wrap(err, fmt.Sprintf("failed to remove %s", filename))
+		}
+		removed = append(removed, filename)
+	}
+	return removed, nil
+}
+
+func init() {
+	walletCmd.AddCommand(walletBackupCmd)
+	walletFlags(walletBackupCmd)
+	walletBackupCmd.Flags().StringVar(&walletBackupDir, "dir", "", "Directory in which to write the backup")
+	walletBackupCmd.PersistentFlags().StringVar(&walletBackupPassphrase, "backuppassphrase", "", "Passphrase protecting the backup")
+	walletBackupCmd.Flags().IntVar(&walletBackupRetain, "retain", 0, "Number of backups to retain in the directory (0 to retain all)")
+}
@@ -0,0 +1,255 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
+	"github.com/wealdtech/go-bytesutil"
+	"github.com/wealdtech/go-ecodec"
+	e2util "github.com/wealdtech/go-eth2-util"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	distributed "github.com/wealdtech/go-eth2-wallet-distributed"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	nd "github.com/wealdtech/go-eth2-wallet-nd/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// walletBackupAccount is the information about an account held in a wallet export.
+type walletBackupAccount struct {
+	ID     uuid.UUID `json:"uuid"`
+	Name   string    `json:"name"`
+	PubKey string    `json:"pubkey"`
+	Path   string    `json:"path"`
+}
+
+// walletBackupWallet is the information about a wallet held in a wallet export.
+type walletBackupWallet struct {
+	Wallet *struct {
+		ID     uuid.UUID              `json:"uuid"`
+		Name   string                 `json:"name"`
+		Type   string                 `json:"type"`
+		Crypto map[string]interface{} `json:"crypto"`
+	} `json:"wallet"`
+	Accounts []*walletBackupAccount `json:"accounts"`
+}
+
+var walletBackupVerifyFile string
+
+var walletBackupVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a wallet backup",
+	Long: `Verify a wallet backup against the wallets in the store.  For example:
+
+    ethdo wallet backup verify --file=/backups/ethdo-backup-20201001T120000Z.json --backuppassphrase="my backup secret"
+
+The backup is decrypted and imported in to a scratch store in memory, and the UUID, name and public key of each account is compared with the store.  If --passphrase is supplied the account keys in the backup are decrypted and checked against the public keys in the store, and for hierarchical deterministic wallets if --walletpassphrase is supplied the keys are also derived from the seed in the backup and checked.  Without these the key material in the backup is not checked, and a warning is given.  Missing and divergent accounts are reported.  If --wallet is supplied only that wallet is verified.
+
+In quiet mode this will return 0 if the backup matches the store, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(viper.GetString("remote") == "", "wallet backup verify not available with remote wallets")
+		assert(walletBackupVerifyFile != "", "--file is required")
+		assert(walletBackupPassphrase != "", "--backuppassphrase is required")
+
+		data, err := ioutil.ReadFile(walletBackupVerifyFile)
+		errCheck(err, "Failed to read backup")
+		archive := &walletBackupArchive{}
+		errCheck(json.Unmarshal(data, archive), "Failed to parse backup")
+		assert(archive.Version == walletBackupVersion, fmt.Sprintf("Unsupported backup version %d", archive.Version))
+		outputIf(verbose, fmt.Sprintf("Backup created %s", archive.Created))
+
+		walletFound := false
+		problems := 0
+		accounts := 0
+		unchecked := 0
+		for i := range archive.Wallets {
+			backup, err := walletBackupDecrypt(archive.Wallets[i], walletBackupPassphrase)
+			errCheck(err, fmt.Sprintf("Failed to decrypt wallet %d in backup", i+1))
+			if viper.GetString("wallet") != "" && backup.Wallet.Name != viper.GetString("wallet") {
+				continue
+			}
+			walletFound = true
+			accounts += len(backup.Accounts)
+
+			walletProblems, walletUnchecked := walletBackupCompare(ctx, archive.Wallets[i], backup)
+			unchecked += walletUnchecked
+			for _, problem := range walletProblems {
+				outputIf(!quiet, fmt.Sprintf("%s: %s", backup.Wallet.Name, problem))
+			}
+			if len(walletProblems) == 0 {
+				outputIf(verbose, fmt.Sprintf("%s: %d accounts verified", backup.Wallet.Name, len(backup.Accounts)))
+			}
+			problems += len(walletProblems)
+		}
+		assert(walletFound, "Wallet not found in backup")
+
+		if problems > 0 {
+			outputIf(!quiet, fmt.Sprintf("Backup does not match store: %d problems found", problems))
+			os.Exit(_exitFailure)
+		}
+		outputIf(!quiet && unchecked > 0, fmt.Sprintf("Key material not checked for %d accounts; supply --passphrase or --walletpassphrase to check it", unchecked))
+		outputIf(!quiet, fmt.Sprintf("Backup matches store: %d accounts verified", accounts))
+		os.Exit(_exitSuccess)
+	},
+}
+
+// walletBackupDecrypt decrypts a wallet export held in a backup archive.
+func walletBackupDecrypt(input string, passphrase string) (*walletBackupWallet, error) {
+	exportData, err := bytesutil.FromHexString(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid wallet data")
+	}
+	data, err := ecodec.Decrypt(exportData, []byte(passphrase))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt wallet")
+	}
+	res := &walletBackupWallet{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, errors.Wrap(err, "failed to parse wallet")
+	}
+	if res.Wallet == nil {
+		return nil, errors.New("wallet information missing")
+	}
+	return res, nil
+}
+
+// walletBackupImport imports a wallet export held in a backup archive in to a scratch store, so that its contents can
+// be examined without touching the real store.
+func walletBackupImport(ctx context.Context, input string, walletType string) (e2wtypes.Wallet, error) {
+	exportData, err := bytesutil.FromHexString(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid wallet data")
+	}
+	store := util.NewMemoryStore()
+	encryptor := keystorev4.New()
+	switch walletType {
+	case "non-deterministic":
+		return nd.Import(ctx, exportData, []byte(walletBackupPassphrase), store, encryptor)
+	case "hierarchical deterministic":
+		return hd.Import(ctx, exportData, []byte(walletBackupPassphrase), store, encryptor)
+	case "distributed":
+		return distributed.Import(ctx, exportData, []byte(walletBackupPassphrase), store, encryptor)
+	default:
+		return nil, fmt.Errorf("unsupported wallet type %q", walletType)
+	}
+}
+
+// walletBackupCompare compares a wallet backup with the store, returning a list of problems found and the number of
+// accounts whose key material could not be checked.
+func walletBackupCompare(ctx context.Context, input string, backup *walletBackupWallet) ([]string, int) {
+	wallet, err := e2wallet.OpenWallet(backup.Wallet.Name)
+	if err != nil {
+		return []string{"wallet not in store"}, 0
+	}
+	imported, err := walletBackupImport(ctx, input, backup.Wallet.Type)
+	if err != nil {
+		return []string{fmt.Sprintf("backup cannot be imported: %v", err)}, 0
+	}
+
+	problems := make([]string, 0)
+	if wallet.ID() != backup.Wallet.ID {
+		problems = append(problems, fmt.Sprintf("wallet UUID %s in backup but %s in store", backup.Wallet.ID, wallet.ID()))
+	}
+
+	var seed []byte
+	if backup.Wallet.Type == "hierarchical deterministic" && getWalletPassphrase() != "" {
+		seed, err = keystorev4.New().Decrypt(backup.Wallet.Crypto, getWalletPassphrase())
+		if err != nil {
+			problems = append(problems, "seed in backup cannot be decrypted with the wallet passphrase")
+		}
+	}
+	backupPaths := make(map[uuid.UUID]string, len(backup.Accounts))
+	for _, backupAccount := range backup.Accounts {
+		backupPaths[backupAccount.ID] = backupAccount.Path
+	}
+
+	storeAccounts := make(map[uuid.UUID]e2wtypes.Account)
+	for account := range wallet.Accounts(ctx) {
+		storeAccounts[account.ID()] = account
+	}
+	unchecked := 0
+	importedAccounts := 0
+	for backupAccount := range imported.Accounts(ctx) {
+		importedAccounts++
+		account, exists := storeAccounts[backupAccount.ID()]
+		if !exists {
+			problems = append(problems, fmt.Sprintf("account %s (%s) in backup but not in store", backupAccount.Name(), backupAccount.ID()))
+			continue
+		}
+		delete(storeAccounts, backupAccount.ID())
+		if account.Name() != backupAccount.Name() {
+			problems = append(problems, fmt.Sprintf("account %s named %s in backup but %s in store", backupAccount.ID(), backupAccount.Name(), account.Name()))
+		}
+		pubKeyProvider, ok := account.(e2wtypes.AccountPublicKeyProvider)
+		if !ok {
+			continue
+		}
+		storePubKey := pubKeyProvider.PublicKey().Marshal()
+		if backupPubKeyProvider, ok := backupAccount.(e2wtypes.AccountPublicKeyProvider); ok && !bytes.Equal(backupPubKeyProvider.PublicKey().Marshal(), storePubKey) {
+			problems = append(problems, fmt.Sprintf("account %s public key %#x in backup but %#x in store", backupAccount.Name(), backupPubKeyProvider.PublicKey().Marshal(), storePubKey))
+			continue
+		}
+
+		// Check that the key material in the backup reproduces the public key.
+		checked := false
+		if len(getPassphrases()) > 0 {
+			privateKey, err := accountPrivateKey(ctx, backupAccount)
+			switch {
+			case err != nil:
+				problems = append(problems, fmt.Sprintf("account %s key in backup cannot be decrypted with the supplied passphrases", backupAccount.Name()))
+			case !bytes.Equal(privateKey.PublicKey().Marshal(), storePubKey):
+				problems = append(problems, fmt.Sprintf("account %s key in backup does not match its public key", backupAccount.Name()))
+			}
+			checked = true
+		}
+		if seed != nil && backupPaths[backupAccount.ID()] != "" {
+			privateKey, err := e2util.PrivateKeyFromSeedAndPath(seed, backupPaths[backupAccount.ID()])
+			if err != nil || !bytes.Equal(privateKey.PublicKey().Marshal(), storePubKey) {
+				problems = append(problems, fmt.Sprintf("account %s key derived from seed in backup does not match its public key", backupAccount.Name()))
+			}
+			checked = true
+		}
+		if !checked {
+			unchecked++
+		}
+	}
+	if importedAccounts != len(backup.Accounts) {
+		problems = append(problems, fmt.Sprintf("%d accounts in backup but only %d could be imported", len(backup.Accounts), importedAccounts))
+	}
+	for _, account := range storeAccounts {
+		problems = append(problems, fmt.Sprintf("account %s (%s) in store but not in backup", account.Name(), account.ID()))
+	}
+
+	return problems, unchecked
+}
+
+func init() {
+	walletBackupCmd.AddCommand(walletBackupVerifyCmd)
+	walletFlags(walletBackupVerifyCmd)
+	walletBackupVerifyCmd.Flags().StringVar(&walletBackupVerifyFile, "file", "", "The backup to verify")
+}
@@ -24,11 +24,12 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/wealdtech/ethdo/util"
 	distributed "github.com/wealdtech/go-eth2-wallet-distributed"
 	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
 	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
 	nd "github.com/wealdtech/go-eth2-wallet-nd/v2"
-	"golang.org/x/text/unicode/norm"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
 var walletCreateCmd = &cobra.Command{
@@ -47,21 +48,40 @@ In quiet mode this will return 0 if the wallet is created successfully, otherwis
 		assert(viper.GetString("wallet") != "", "--wallet is required")
 		assert(viper.GetString("type") != "", "--type is required")
 
-		var err error
+		kdfParams, err := kdfParamsFromInput()
+		errCheck(err, "Invalid key derivation parameters")
+
 		switch strings.ToLower(viper.GetString("type")) {
 		case "non-deterministic", "nd":
 			assert(viper.GetString("mnemonic") == "", "--mnemonic is not allowed with non-deterministic wallets")
-			err = walletCreateND(ctx, viper.GetString("wallet"))
+			assert(len(walletCreateMnemonicShares) == 0, "--mnemonic-share is not allowed with non-deterministic wallets")
+			err = walletCreateND(ctx, viper.GetString("wallet"), kdfParams)
 		case "hierarchical deterministic", "hd":
 			if quiet {
 				fmt.Printf("Creation of hierarchical deterministic wallets prints its mnemonic, so cannot be run with the --quiet flag")
 				os.Exit(_exitFailure)
 			}
 			assert(getWalletPassphrase() != "", "--walletpassphrase is required for hierarchical deterministic wallets")
-			err = walletCreateHD(ctx, viper.GetString("wallet"), getWalletPassphrase(), viper.GetString("mnemonic"))
+			mnemonic := viper.GetString("mnemonic")
+			if len(walletCreateMnemonicShares) > 0 {
+				assert(mnemonic == "", "--mnemonic and --mnemonic-share are mutually exclusive")
+				assert(viper.GetInt("mnemonic-shares") == 0, "--mnemonic-shares is not allowed with --mnemonic-share")
+				entropy, err := util.EntropyFromMnemonicShares(walletCreateMnemonicShares)
+				errCheck(err, "Failed to recreate mnemonic from shares")
+				mnemonic, err = bip39.NewMnemonic(entropy)
+				errCheck(err, "Failed to recreate mnemonic from shares")
+			}
+			if viper.GetInt("mnemonic-shares") > 0 {
+				assert(mnemonic == "", "--mnemonic-shares is only available when generating a new mnemonic")
+				assert(viper.GetInt("mnemonic-threshold") > 1, "--mnemonic-threshold must be at least 2")
+				assert(viper.GetInt("mnemonic-threshold") <= viper.GetInt("mnemonic-shares"), "--mnemonic-threshold cannot be more than --mnemonic-shares")
+				assert(viper.GetInt("mnemonic-shares") < 256, "--mnemonic-shares must be less than 256")
+			}
+			err = walletCreateHD(ctx, viper.GetString("wallet"), getWalletPassphrase(), mnemonic, viper.GetInt("mnemonic-threshold"), viper.GetInt("mnemonic-shares"), kdfParams)
 		case "distributed":
 			assert(viper.GetString("mnemonic") == "", "--mnemonic is not allowed with distributed wallets")
-			err = walletCreateDistributed(ctx, viper.GetString("wallet"))
+			assert(len(walletCreateMnemonicShares) == 0, "--mnemonic-share is not allowed with distributed wallets")
+			err = walletCreateDistributed(ctx, viper.GetString("wallet"), kdfParams)
 		default:
 			die("unknown wallet type")
 		}
@@ -70,23 +90,58 @@ In quiet mode this will return 0 if the wallet is created successfully, otherwis
 }
 
 // walletCreateND creates a non-deterministic wallet.
-func walletCreateND(ctx context.Context, name string) error {
-	_, err := nd.CreateWallet(ctx, name, store, keystorev4.New())
-	return err
+// If kdfParams is supplied it is recorded in the wallet and used to encrypt its accounts.
+func walletCreateND(ctx context.Context, name string, kdfParams *util.KDFParams) error {
+	encryptor, err := walletCreateEncryptor(kdfParams)
+	if err != nil {
+		return err
+	}
+	wallet, err := nd.CreateWallet(ctx, name, store, encryptor)
+	if err != nil {
+		return err
+	}
+	if kdfParams != nil {
+		return setWalletKDFParams(wallet, kdfParams)
+	}
+	return nil
 }
 
 // walletCreateDistributed creates a distributed wallet.
-func walletCreateDistributed(ctx context.Context, name string) error {
-	_, err := distributed.CreateWallet(ctx, name, store, keystorev4.New())
-	return err
+// If kdfParams is supplied it is recorded in the wallet and used to encrypt its accounts.
+func walletCreateDistributed(ctx context.Context, name string, kdfParams *util.KDFParams) error {
+	encryptor, err := walletCreateEncryptor(kdfParams)
+	if err != nil {
+		return err
+	}
+	wallet, err := distributed.CreateWallet(ctx, name, store, encryptor)
+	if err != nil {
+		return err
+	}
+	if kdfParams != nil {
+		return setWalletKDFParams(wallet, kdfParams)
+	}
+	return nil
+}
+
+// walletCreateEncryptor returns the encryptor for a new wallet, using the default encryptor if kdfParams is not supplied.
+func walletCreateEncryptor(kdfParams *util.KDFParams) (e2wtypes.Encryptor, error) {
+	if kdfParams == nil {
+		return keystorev4.New(), nil
+	}
+	return util.NewKeystoreEncryptor(kdfParams)
 }
 
 // walletCreateHD creates a hierarchical-deterministic wallet.
-func walletCreateHD(ctx context.Context, name string, passphrase string, mnemonic string) error {
-	encryptor := keystorev4.New()
+// If shares is non-zero a newly generated mnemonic is split in to shares, threshold of which are required to recreate it.
+// If kdfParams is supplied it is used to encrypt the wallet's seed and, subsequently, its accounts.
+func walletCreateHD(ctx context.Context, name string, passphrase string, mnemonic string, threshold int, shares int, kdfParams *util.KDFParams) error {
+	encryptor, err := walletCreateEncryptor(kdfParams)
+	if err != nil {
+		return err
+	}
 
 	printMnemonic := mnemonic == ""
-	mnemonicPassphrase := ""
+	var mnemonicShares []string
 
 	if mnemonic == "" {
 		// Create a new random mnemonic.
@@ -99,27 +154,37 @@ func walletCreateHD(ctx context.Context, name string, passphrase string, mnemoni
 		if err != nil {
 			return errors.Wrap(err, "failed to generate wallet mnemonic")
 		}
-	} else {
-		// We have an existing mnemonic.  If there are more than 24 words we treat the additional characters as the passphrase.
-		mnemonicParts := strings.Split(mnemonic, " ")
-		if len(mnemonicParts) > 24 {
-			mnemonic = strings.Join(mnemonicParts[:24], " ")
-			mnemonicPassphrase = strings.Join(mnemonicParts[24:], " ")
+		if shares > 0 {
+			mnemonicShares, err = util.MnemonicSharesFromEntropy(entropy, threshold, shares)
+			if err != nil {
+				return errors.Wrap(err, "failed to generate mnemonic shares")
+			}
 		}
 	}
-	// Normalise the input.
-	mnemonic = string(norm.NFKD.Bytes([]byte(mnemonic)))
-	mnemonicPassphrase = string(norm.NFKD.Bytes([]byte(mnemonicPassphrase)))
 
-	// Ensure the mnemonic is valid
-	if !bip39.IsMnemonicValid(mnemonic) {
-		return errors.New("mnemonic is not valid")
+	// Create seed from mnemonic and passphrase.
+	seed, err := util.SeedFromMnemonic(mnemonic)
+	if err != nil {
+		return err
 	}
 
-	// Create seed from mnemonic and passphrase.
-	seed := bip39.NewSeed(mnemonic, mnemonicPassphrase)
+	_, err = hd.CreateWallet(ctx, name, []byte(passphrase), store, encryptor, seed)
+	if err != nil {
+		return err
+	}
+
+	if len(mnemonicShares) > 0 {
+		fmt.Printf("The mnemonic for this wallet has been split in to %d shares, any %d of which can recreate the accounts in this wallet.\n", shares, threshold)
+		for i := range mnemonicShares {
+			fmt.Printf("\nShare %d of %d:\n\n%s\n", i+1, shares, mnemonicShares[i])
+		}
+		fmt.Printf(`
+Each share should be written down and stored separately.  The wallet can be recreated by passing the required number of shares to this command with --mnemonic-share.  The shares are specific to ethdo, and cannot be recombined by SLIP-39 tools.
 
-	_, err := hd.CreateWallet(ctx, name, []byte(passphrase), store, encryptor, seed)
+Please note these shares are not stored within the wallet, so cannot be retrieved or displayed again.
+`)
+		return nil
+	}
 
 	if printMnemonic {
 		fmt.Printf(`The following phrase is your mnemonic for this wallet:
@@ -132,14 +197,20 @@ Please note this mnemonic is not stored within the wallet, so cannot be retrieve
 `, mnemonic)
 	}
 
-	return err
+	return nil
 }
 
+var walletCreateMnemonicShares []string
+
 func init() {
 	walletCmd.AddCommand(walletCreateCmd)
 	walletFlags(walletCreateCmd)
+	kdfFlags(walletCreateCmd)
 	walletCreateCmd.Flags().String("type", "non-deterministic", "Type of wallet to create (non-deterministic or hierarchical deterministic)")
-	walletCreateCmd.Flags().String("mnemonic", "", "The 24-word mnemonic for a hierarchical deterministic wallet")
+	walletCreateCmd.Flags().String("mnemonic", "", "The mnemonic for a hierarchical deterministic wallet")
+	walletCreateCmd.Flags().Int("mnemonic-shares", 0, "Split the generated mnemonic in to this number of shares")
+	walletCreateCmd.Flags().Int("mnemonic-threshold", 0, "The number of mnemonic shares required to recreate the wallet")
+	walletCreateCmd.Flags().StringArrayVar(&walletCreateMnemonicShares, "mnemonic-share", nil, "A mnemonic share from which to recreate a hierarchical deterministic wallet (can be supplied multiple times)")
 }
 
 func walletCreateBindings() {
@@ -149,4 +220,10 @@ func walletCreateBindings() {
 	if err := viper.BindPFlag("mnemonic", walletCreateCmd.Flags().Lookup("mnemonic")); err != nil {
 		panic(err)
 	}
+	if err := viper.BindPFlag("mnemonic-shares", walletCreateCmd.Flags().Lookup("mnemonic-shares")); err != nil {
+		panic(err)
+	}
+	if err := viper.BindPFlag("mnemonic-threshold", walletCreateCmd.Flags().Lookup("mnemonic-threshold")); err != nil {
+		panic(err)
+	}
 }
@@ -14,15 +14,44 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/grpc"
 	wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
+// walletTrashDir is the directory within a store that holds deleted wallets.
+// It is not a valid wallet ID so is ignored by the store when listing wallets.
+const walletTrashDir = ".trash"
+
+// walletTombstoneFile is the name of the file in a trash entry that describes the deleted wallet.
+const walletTombstoneFile = "tombstone.json"
+
+// walletTombstone describes a deleted wallet held in the trash.
+type walletTombstone struct {
+	ID      uuid.UUID `json:"uuid"`
+	Name    string    `json:"name"`
+	Deleted time.Time `json:"deleted"`
+	// Entry is the path of the trash entry; it is not stored.
+	Entry string `json:"-"`
+}
+
+var walletDeleteYes bool
+var walletDeleteForce bool
+
 var walletDeleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a wallet",
@@ -30,6 +59,10 @@ var walletDeleteCmd = &cobra.Command{
 
     ethdo wallet delete --wallet=primary
 
+The name of the wallet must be typed to confirm the deletion, unless --yes is supplied.  Deletion is refused if any account in the wallet is known to the beacon chain as a validator, including one that has deposited but is not yet pending, or if the state of the accounts cannot be obtained from the beacon node, unless --force is supplied.
+
+Deleted wallets are moved to the store's trash, from where they can be brought back with "wallet restore" or removed permanently with "wallet purge".
+
 In quiet mode this will return 0 if the wallet has been deleted, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		assert(viper.GetString("remote") == "", "wallet delete not available with remote wallets")
@@ -41,20 +74,159 @@ In quiet mode this will return 0 if the wallet has been deleted, otherwise 1.`,
 		wallet, err := walletFromPath(ctx, viper.GetString("wallet"))
 		errCheck(err, "Failed to access wallet")
 
-		storeProvider, ok := wallet.(wtypes.StoreProvider)
-		assert(ok, "Cannot obtain store for the wallet")
-		store := storeProvider.Store()
-		storeLocationProvider, ok := store.(wtypes.StoreLocationProvider)
-		assert(ok, "Cannot obtain store location for the wallet")
-		walletLocation := filepath.Join(storeLocationProvider.Location(), wallet.ID().String())
-		err = os.RemoveAll(walletLocation)
+		location, err := walletStoreLocation(wallet)
+		errCheck(err, "Failed to obtain store location for the wallet")
+
+		if !walletDeleteForce {
+			accounts := make([]wtypes.Account, 0)
+			for account := range wallet.Accounts(ctx) {
+				accounts = append(accounts, account)
+			}
+			live, err := liveValidatorAccounts(accounts)
+			errCheck(err, "Failed to check validator state of accounts; use --force to delete regardless")
+			for _, account := range live {
+				outputIf(!quiet, fmt.Sprintf("Account %s is a validator", account.Name()))
+			}
+			assert(len(live) == 0, "Wallet contains validators; use --force to delete regardless")
+		}
+
+		if !walletDeleteYes {
+			assert(confirmInput(fmt.Sprintf("Type the name of the wallet (%s) to confirm deletion: ", wallet.Name()), wallet.Name()), "Wallet name not confirmed")
+		}
+
+		tombstone, err := walletToTrash(location, wallet.ID(), wallet.Name())
 		errCheck(err, "Failed to delete wallet")
+		outputIf(verbose, fmt.Sprintf("Wallet moved to %s", tombstone.Entry))
 
 		os.Exit(_exitSuccess)
 	},
 }
 
+// walletStoreLocation returns the location of the filesystem store that holds the wallet.
+func walletStoreLocation(wallet wtypes.Wallet) (string, error) {
+	storeProvider, ok := wallet.(wtypes.StoreProvider)
+	if !ok {
+		return "", errors.New("cannot obtain store for the wallet")
+	}
+	if storeProvider.Store().Name() != "filesystem" {
+		return "", errors.New("only available for wallets in a filesystem store")
+	}
+	storeLocationProvider, ok := storeProvider.Store().(wtypes.StoreLocationProvider)
+	if !ok {
+		return "", errors.New("cannot obtain store location for the wallet")
+	}
+	return storeLocationProvider.Location(), nil
+}
+
+// liveValidatorAccounts returns the accounts that are known as validators on the beacon chain.
+func liveValidatorAccounts(accounts []wtypes.Account) ([]wtypes.Account, error) {
+	if err := connect(); err != nil {
+		return nil, errors.Wrap(err, "failed to connect to beacon node")
+	}
+	live := make([]wtypes.Account, 0)
+	for _, account := range accounts {
+		state, err := grpc.FetchValidatorState(eth2GRPCConn, account)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain state of account %s", account.Name()))
+		}
+		if validatorKnown(state) {
+			live = append(live, account)
+		}
+	}
+	return live, nil
+}
+
+// confirmInput prompts the user and returns true if they type the expected text.
+func confirmInput(prompt string, expected string) bool {
+	fmt.Print(prompt)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(input) == expected
+}
+
+// walletToTrash moves a wallet in to the trash of its store, recording a tombstone alongside it.
+func walletToTrash(location string, walletID uuid.UUID, walletName string) (*walletTombstone, error) {
+	tombstone := &walletTombstone{
+		ID:      walletID,
+		Name:    walletName,
+		Deleted: time.Now().UTC(),
+	}
+	tombstone.Entry = filepath.Join(location, walletTrashDir, fmt.Sprintf("%s-%s", tombstone.Deleted.Format("20060102T150405Z"), tombstone.ID))
+	if err := os.MkdirAll(tombstone.Entry, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create trash entry")
+	}
+	// The tombstone is only written once the wallet is in the trash, so a failed move leaves no trash entry behind.
+	walletDir := filepath.Join(location, tombstone.ID.String())
+	trashedWalletDir := filepath.Join(tombstone.Entry, tombstone.ID.String())
+	if err := os.Rename(walletDir, trashedWalletDir); err != nil {
+		os.RemoveAll(tombstone.Entry)
+		return nil, errors.Wrap(err, "failed to move wallet to trash")
+	}
+	data, err := json.Marshal(tombstone)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(tombstone.Entry, walletTombstoneFile), data, 0600)
+	}
+	if err != nil {
+		// Put the wallet back rather than leave it in the trash without a tombstone.
+		if restoreErr := os.Rename(trashedWalletDir, walletDir); restoreErr != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to write tombstone, and failed to restore wallet from %s", trashedWalletDir))
+		}
+		os.RemoveAll(tombstone.Entry)
+		return nil, errors.Wrap(err, "failed to write tombstone")
+	}
+	return tombstone, nil
+}
+
+// walletTombstones returns the tombstones of the wallets in the trash of a store, oldest first.
+func walletTombstones(location string) ([]*walletTombstone, error) {
+	trashDir := filepath.Join(location, walletTrashDir)
+	entries, err := ioutil.ReadDir(trashDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*walletTombstone{}, nil
+		}
+		return nil, err
+	}
+	tombstones := make([]*walletTombstone, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(trashDir, entry.Name(), walletTombstoneFile))
+		if err != nil {
+			// Not a trash entry.
+			continue
+		}
+		tombstone := &walletTombstone{}
+		if err := json.Unmarshal(data, tombstone); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid tombstone in %s", entry.Name()))
+		}
+		tombstone.Entry = filepath.Join(trashDir, entry.Name())
+		tombstones = append(tombstones, tombstone)
+	}
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].Deleted.Before(tombstones[j].Deleted)
+	})
+	return tombstones, nil
+}
+
+// defaultStoreLocation returns the location of the default filesystem store.
+func defaultStoreLocation() (string, error) {
+	if store.Name() != "filesystem" {
+		return "", errors.New("only available for filesystem stores")
+	}
+	storeLocationProvider, ok := store.(wtypes.StoreLocationProvider)
+	if !ok {
+		return "", errors.New("store does not provide a location")
+	}
+	return storeLocationProvider.Location(), nil
+}
+
 func init() {
 	walletCmd.AddCommand(walletDeleteCmd)
 	walletFlags(walletDeleteCmd)
+	walletDeleteCmd.Flags().BoolVar(&walletDeleteYes, "yes", false, "Do not ask for confirmation of the wallet name")
+	walletDeleteCmd.Flags().BoolVar(&walletDeleteForce, "force", false, "Delete the wallet even if its accounts are validators")
 }
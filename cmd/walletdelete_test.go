@@ -0,0 +1,80 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWalletToTrash(t *testing.T) {
+	location, err := ioutil.TempDir("", "ethdo-walletdelete-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(location)
+
+	walletID := uuid.New()
+	if err := os.MkdirAll(filepath.Join(location, walletID.String()), 0700); err != nil {
+		t.Fatal(err)
+	}
+	tombstone, err := walletToTrash(location, walletID, "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tombstone.Entry, walletID.String())); err != nil {
+		t.Fatalf("wallet not in trash: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(location, walletID.String())); !os.IsNotExist(err) {
+		t.Fatal("wallet still in store")
+	}
+	tombstones, err := walletTombstones(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tombstones) != 1 || tombstones[0].ID != walletID || tombstones[0].Name != "Test" {
+		t.Fatalf("unexpected tombstones %v", tombstones)
+	}
+}
+
+func TestWalletToTrashMoveFails(t *testing.T) {
+	location, err := ioutil.TempDir("", "ethdo-walletdelete-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(location)
+
+	// The wallet directory does not exist, so the move fails.
+	if _, err := walletToTrash(location, uuid.New(), "Test"); err == nil {
+		t.Fatal("expected error moving missing wallet")
+	}
+	tombstones, err := walletTombstones(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tombstones) != 0 {
+		t.Fatalf("failed move left %d tombstones", len(tombstones))
+	}
+	entries, err := ioutil.ReadDir(filepath.Join(location, walletTrashDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("failed move left %d trash entries", len(entries))
+	}
+}
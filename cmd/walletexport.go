@@ -15,15 +15,24 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
 	types "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
 var walletExportPassphrase string
+var walletExportTo string
+var walletExportPath string
+var walletExportKeystorePassphrase string
+var walletExportSlashingProtection string
 
 var walletExportCmd = &cobra.Command{
 	Use:   "export",
@@ -32,12 +41,22 @@ var walletExportCmd = &cobra.Command{
 
     ethdo wallet export --wallet=primary --exportpassphrase="my export secret"
 
+Validator keys can also be written in to the on-disk layout of a client, ready for the client to use.  For example:
+
+    ethdo wallet export --wallet=Validators --to=lighthouse --path=/var/lib/lighthouse --passphrase="my account secret"
+
+--to can be "lighthouse", "teku", "nimbus" or "prysm".  All accounts in the wallet are exported, or those matching --account or --select.  Each keystore is encrypted with --keystorepassphrase, or if not supplied with a random passphrase written to the client's password file; Prysm requires --keystorepassphrase as its wallet passphrase.  If --slashing-protection names an EIP-3076 slashing protection file, the records for the exported keys are written to slashing_protection.json.  The path must be empty or not yet exist.
+
 In quiet mode this will return 0 if the wallet is able to be exported, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
 		defer cancel()
 
 		assert(viper.GetString("remote") == "", "wallet export not available with remote wallets")
+		if walletExportTo != "" {
+			walletExportToClient(ctx)
+		}
+		assert(walletExportPath == "" && walletExportKeystorePassphrase == "" && walletExportSlashingProtection == "", "--path, --keystorepassphrase and --slashing-protection require --to")
 		assert(viper.GetString("wallet") != "", "--wallet is required")
 		assert(walletExportPassphrase != "", "--exportpassphrase is required")
 
@@ -55,8 +74,84 @@ In quiet mode this will return 0 if the wallet is able to be exported, otherwise
 	},
 }
 
+// walletExportToClient writes the keys of accounts in to the on-disk layout of a client.
+func walletExportToClient(ctx context.Context) {
+	assert(walletExportPassphrase == "", "--exportpassphrase cannot be used with --to")
+	assert(walletExportPath != "", "--path is required with --to")
+	assert(walletExportTo != "prysm" || walletExportKeystorePassphrase != "", "--keystorepassphrase is required for prysm")
+
+	var accounts []*selectedAccount
+	var err error
+	switch {
+	case viper.GetString("select") != "":
+		accounts, err = accountsFromInput(ctx, "")
+	case viper.GetString("account") != "":
+		accounts, err = accountsFromInput(ctx, viper.GetString("account"))
+	default:
+		assert(viper.GetString("wallet") != "", "--wallet, --account or --select is required")
+		accounts, err = accountsFromInput(ctx, viper.GetString("wallet"))
+	}
+	errCheck(err, "Failed to obtain accounts")
+	assert(len(accounts) > 0, "No accounts to export")
+
+	keys := make([]*util.ClientExportKey, 0, len(accounts))
+	pubKeys := make([][]byte, 0, len(accounts))
+	for _, selected := range accounts {
+		name := fmt.Sprintf("%s/%s", selected.wallet.Name(), selected.account.Name())
+		assert(selected.wallet.Type() != "distributed", fmt.Sprintf("Account %s is distributed; its key cannot be exported", name))
+		privateKey, err := accountPrivateKey(ctx, selected.account)
+		errCheck(err, fmt.Sprintf("Failed to obtain private key for account %s", name))
+		key := &util.ClientExportKey{
+			PrivateKey: privateKey.Marshal(),
+			PublicKey:  privateKey.PublicKey().Marshal(),
+			Passphrase: walletExportKeystorePassphrase,
+		}
+		if pathProvider, ok := selected.account.(types.AccountPathProvider); ok {
+			key.Path = pathProvider.Path()
+		}
+		if key.Passphrase == "" {
+			passphrase := make([]byte, 32)
+			_, err := rand.Read(passphrase)
+			errCheck(err, "Failed to generate passphrase")
+			key.Passphrase = hex.EncodeToString(passphrase)
+		}
+		keys = append(keys, key)
+		pubKeys = append(pubKeys, key.PublicKey)
+		outputIf(debug, fmt.Sprintf("Exporting account %s with public key %#x", name, key.PublicKey))
+	}
+
+	// Prepare slashing protection before writing keys, so that invalid data does not leave a partial export.
+	var slashingProtection []byte
+	if walletExportSlashingProtection != "" {
+		data, err := ioutil.ReadFile(walletExportSlashingProtection)
+		errCheck(err, "Failed to read slashing protection file")
+		var records int
+		slashingProtection, records, err = util.FilterSlashingProtection(data, pubKeys)
+		errCheck(err, "Invalid slashing protection file")
+		outputIf(verbose, fmt.Sprintf("Slashing protection records found for %d of %d keys", records, len(keys)))
+	}
+
+	files, err := util.WriteClientKeystores(walletExportTo, walletExportPath, keys, walletExportKeystorePassphrase)
+	for _, file := range files {
+		outputIf(verbose, fmt.Sprintf("Wrote %s", file))
+	}
+	errCheck(err, "Failed to write keys")
+	if slashingProtection != nil {
+		filename := filepath.Join(walletExportPath, "slashing_protection.json")
+		errCheck(ioutil.WriteFile(filename, slashingProtection, 0600), "Failed to write slashing protection file")
+		outputIf(verbose, fmt.Sprintf("Wrote %s", filename))
+	}
+	outputIf(!quiet, fmt.Sprintf("Exported %d keys to %s", len(keys), walletExportPath))
+	os.Exit(_exitSuccess)
+}
+
 func init() {
 	walletCmd.AddCommand(walletExportCmd)
 	walletFlags(walletExportCmd)
+	selectFlags(walletExportCmd)
 	walletExportCmd.Flags().StringVar(&walletExportPassphrase, "exportpassphrase", "", "Passphrase to protect the export")
+	walletExportCmd.Flags().StringVar(&walletExportTo, "to", "", "Client for which to write keys (lighthouse, teku, nimbus or prysm)")
+	walletExportCmd.Flags().StringVar(&walletExportPath, "path", "", "Directory in which to write keys for the client")
+	walletExportCmd.Flags().StringVar(&walletExportKeystorePassphrase, "keystorepassphrase", "", "Passphrase for the keystores written for the client (defaults to a random passphrase for each keystore)")
+	walletExportCmd.Flags().StringVar(&walletExportSlashingProtection, "slashing-protection", "", "EIP-3076 slashing protection file to filter to the exported keys")
 }
@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -23,14 +24,21 @@ import (
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/ethdo/util"
 	"github.com/wealdtech/go-bytesutil"
 	"github.com/wealdtech/go-ecodec"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
 	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
 var walletImportData string
 var walletImportPassphrase string
 var walletImportVerify bool
+var walletImportFrom string
+var walletImportPath string
+var walletImportPasswordsPath string
+var walletImportKeystorePassphrase string
 
 var walletImportCmd = &cobra.Command{
 	Use:   "import",
@@ -39,9 +47,21 @@ var walletImportCmd = &cobra.Command{
 
     ethdo wallet import --importdata=primary --importpassphrase="my export secret"
 
+The key derivation parameters of non-deterministic and distributed wallets are not held in the export, so are taken from the imported accounts.  A wallet exported without accounts uses the default parameters after import.
+
+Validator keys can also be imported from the on-disk layout of another client in to an existing non-deterministic wallet.  For example:
+
+    ethdo wallet import --from=lighthouse --path=${HOME}/.lighthouse/medalla/validators --wallet=Validators --passphrase="my account secret"
+
+--from can be "prysm" (the wallet directory, with --keystorepassphrase the wallet passphrase), "lighthouse" (the validators directory, with passphrases in the neighbouring secrets directory), "teku" (the keys directory, with passphrases in .txt files alongside or in --passwords-path) or "deposit-cli" (the validator_keys directory, with --keystorepassphrase the keystore passphrase).  --keystorepassphrase is also used for any keystore without its own passphrase file.  Accounts are named for their public keys, and encrypted with --passphrase if supplied or else their existing passphrase.  Keys already in the wallet are skipped.
+
 In quiet mode this will return 0 if the wallet is imported successfully, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		assert(viper.GetString("remote") == "", "wallet import not available with remote wallets")
+		if walletImportFrom != "" {
+			walletImportFromClient()
+		}
+		assert(walletImportPath == "" && walletImportPasswordsPath == "" && walletImportKeystorePassphrase == "", "--path, --passwords-path and --keystorepassphrase require --from")
 		assert(walletImportData != "", "--importdata is required")
 		assert(walletImportPassphrase != "", "--importpassphrase is required")
 		assert(viper.GetString("wallet") == "", "--wallet is not allowed (the wallet will retain its name)")
@@ -90,18 +110,113 @@ In quiet mode this will return 0 if the wallet is imported successfully, otherwi
 			}
 
 		} else {
-			_, err = e2wallet.ImportWallet(importData, []byte(walletImportPassphrase))
+			wallet, err := e2wallet.ImportWallet(importData, []byte(walletImportPassphrase))
 			errCheck(err, "Failed to import wallet")
+			ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+			defer cancel()
+			errCheck(restoreWalletKDFParams(ctx, wallet), "Failed to restore key derivation parameters for wallet")
 		}
 
 		os.Exit(_exitSuccess)
 	},
 }
 
+// walletImportFromClient imports the validator keys in the on-disk layout of a client in to a wallet.
+func walletImportFromClient() {
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+	defer cancel()
+
+	assert(walletImportData == "" && walletImportPassphrase == "", "--importdata and --importpassphrase cannot be used with --from")
+	assert(walletImportPath != "", "--path is required with --from")
+	assert(viper.GetString("wallet") != "", "--wallet is required with --from")
+
+	wallet, err := walletFromInput(ctx)
+	errCheck(err, "Failed to access wallet")
+	assert(wallet.Type() == "non-deterministic", "Keys can only be imported in to non-deterministic wallets")
+	kdfParams, err := walletKDFParams(wallet)
+	errCheck(err, "Failed to obtain key derivation parameters for wallet")
+	wallet, err = walletWithKDFParams(wallet, kdfParams)
+	errCheck(err, "Failed to access wallet")
+	importer, ok := wallet.(e2wtypes.WalletAccountImporter)
+	assert(ok, fmt.Sprintf("wallets of type %q do not allow importing accounts", wallet.Type()))
+	locker, isLocker := wallet.(e2wtypes.WalletLocker)
+	if isLocker {
+		errCheck(locker.Unlock(ctx, []byte(getWalletPassphrase())), "Failed to unlock wallet")
+	}
+
+	keystores, err := util.ReadClientKeystores(walletImportFrom, walletImportPath, walletImportPasswordsPath, walletImportKeystorePassphrase)
+	errCheck(err, "Failed to read keys")
+	assert(len(keystores) > 0, "No keys found")
+
+	existing := make(map[string]bool)
+	for account := range wallet.Accounts(ctx) {
+		if pubKey, err := bestPublicKey(account); err == nil {
+			existing[fmt.Sprintf("%#x", pubKey.Marshal())] = true
+		}
+	}
+
+	imported := 0
+	failed := 0
+	for _, keystore := range keystores {
+		key, err := keystore.Decrypt(walletImportKeystorePassphrase)
+		if err != nil {
+			outputIf(!quiet, fmt.Sprintf("Skipped %s: %v", keystore.Location, err))
+			failed++
+			continue
+		}
+		privateKey, err := e2types.BLSPrivateKeyFromBytes(key)
+		if err != nil {
+			outputIf(!quiet, fmt.Sprintf("Skipped %s: invalid private key", keystore.Location))
+			failed++
+			continue
+		}
+		pubKey := fmt.Sprintf("%#x", privateKey.PublicKey().Marshal())
+		if keystore.PublicKey != nil && fmt.Sprintf("%#x", keystore.PublicKey) != pubKey {
+			outputIf(!quiet, fmt.Sprintf("Skipped %s: private key does not match public key", keystore.Location))
+			failed++
+			continue
+		}
+		if existing[pubKey] {
+			outputIf(!quiet, fmt.Sprintf("Skipped %s: %s already in wallet", keystore.Location, pubKey))
+			continue
+		}
+		passphrase := getOptionalPassphrase()
+		if passphrase == "" {
+			passphrase = keystore.Passphrase
+		}
+		if passphrase == "" {
+			passphrase = walletImportKeystorePassphrase
+		}
+		if passphrase == "" {
+			outputIf(!quiet, fmt.Sprintf("Skipped %s: no passphrase for account; supply --passphrase", keystore.Location))
+			failed++
+			continue
+		}
+		if _, err := importer.ImportAccount(ctx, pubKey, key, []byte(passphrase)); err != nil {
+			outputIf(!quiet, fmt.Sprintf("Skipped %s: %v", keystore.Location, err))
+			failed++
+			continue
+		}
+		existing[pubKey] = true
+		imported++
+		outputIf(!quiet, fmt.Sprintf("Imported %s as %s/%s", keystore.Location, wallet.Name(), pubKey))
+	}
+	outputIf(verbose, fmt.Sprintf("Imported %d of %d keys", imported, len(keystores)))
+
+	if failed > 0 {
+		os.Exit(_exitFailure)
+	}
+	os.Exit(_exitSuccess)
+}
+
 func init() {
 	walletCmd.AddCommand(walletImportCmd)
 	walletFlags(walletImportCmd)
 	walletImportCmd.Flags().StringVar(&walletImportData, "importdata", "", "The data to import, or the name of a file to read")
 	walletImportCmd.Flags().StringVar(&walletImportPassphrase, "importpassphrase", "", "Passphrase protecting the data to import")
 	walletImportCmd.Flags().BoolVar(&walletImportVerify, "verify", false, "Verify the wallet can be imported, but do not import it")
+	walletImportCmd.Flags().StringVar(&walletImportFrom, "from", "", "Client whose keys to import (prysm, lighthouse, teku or deposit-cli)")
+	walletImportCmd.Flags().StringVar(&walletImportPath, "path", "", "Directory holding the client's keys")
+	walletImportCmd.Flags().StringVar(&walletImportPasswordsPath, "passwords-path", "", "Directory holding the client's password files, if not in their usual location")
+	walletImportCmd.Flags().StringVar(&walletImportKeystorePassphrase, "keystorepassphrase", "", "Passphrase for keystores without a password file, or for a Prysm wallet")
 }
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -36,7 +37,6 @@ In quiet mode this will return 0 if the wallet exists, otherwise 1.`,
 		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
 		defer cancel()
 
-		assert(viper.GetString("remote") == "", "wallet info not available with remote wallets")
 		assert(viper.GetString("wallet") != "", "--wallet is required")
 
 		wallet, err := walletFromPath(ctx, viper.GetString("wallet"))
@@ -48,6 +48,13 @@ In quiet mode this will return 0 if the wallet exists, otherwise 1.`,
 
 		outputIf(verbose, fmt.Sprintf("UUID: %v", wallet.ID()))
 		fmt.Printf("Type: %s\n", wallet.Type())
+		if remote {
+			outputIf(verbose, fmt.Sprintf("Remotes: %s", strings.Join(remoteAddresses(), ", ")))
+		} else {
+			kdfParams, err := walletKDFParams(wallet)
+			errCheck(err, "Failed to obtain key derivation parameters")
+			fmt.Printf("Key derivation: %s\n", kdfParams)
+		}
 		if verbose {
 			if storeProvider, ok := wallet.(wtypes.StoreProvider); ok {
 				store := storeProvider.Store()
@@ -60,10 +67,17 @@ In quiet mode this will return 0 if the wallet exists, otherwise 1.`,
 
 		// Count the accounts.
 		accounts := 0
-		for range wallet.Accounts(ctx) {
+		distributedAccounts := 0
+		for account := range wallet.Accounts(ctx) {
 			accounts++
+			if _, isDistributedAccount := account.(wtypes.DistributedAccount); isDistributedAccount {
+				distributedAccounts++
+			}
 		}
 		fmt.Printf("Accounts: %d\n", accounts)
+		if distributedAccounts > 0 {
+			fmt.Printf("Distributed accounts: %d\n", distributedAccounts)
+		}
 	},
 }
 
@@ -31,7 +31,7 @@ var walletListCmd = &cobra.Command{
 
 In quiet mode this will return 0 if any wallets are found, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		assert(viper.GetString("remote") == "", "wallet list not available with remote wallets")
+		assert(viper.GetString("remote") == "", "wallet list not available with remote wallets; remote wallet daemons do not provide a list of their wallets")
 		assert(viper.GetString("wallet") == "", "wallet list does not take a --wallet parameter")
 
 		walletsFound := false
@@ -40,6 +40,7 @@ In quiet mode this will return 0 if any wallets are found, otherwise 1.`,
 			outputIf(!quiet && !verbose, w.Name())
 			outputIf(verbose, fmt.Sprintf("%s\n UUID: %s", w.Name(), w.ID().String()))
 		}
+		errCheck(storeListError(store), "Failed to list wallets")
 
 		if !walletsFound {
 			os.Exit(_exitFailure)
@@ -0,0 +1,199 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var walletMigrateFromStore string
+var walletMigrateFromBaseDir string
+var walletMigrateFromPassphrase string
+var walletMigrateToStore string
+var walletMigrateToBaseDir string
+var walletMigrateToPassphrase string
+var walletMigrateRemoveSource bool
+
+// storeRecord is the information common to the wallet and account records held in a store.
+type storeRecord struct {
+	ID   uuid.UUID `json:"uuid"`
+	Name string    `json:"name"`
+}
+
+var walletMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy wallets between stores",
+	Long: `Copy one or all wallets, along with their accounts, from one store to another.  For example:
+
+    ethdo wallet migrate --wallet=primary --from-store=filesystem --to-store=filesystem --to-basedir=/mnt/wallets
+
+If --from-store is not supplied the wallets are copied from the store given by --store, --basedir and --storepassphrase.  The wallets are encrypted in the destination store with --to-storepassphrase, which defaults to the passphrase of the source store.  Each wallet is verified in the destination store after it is copied, and if it cannot be copied any partial copy is removed from the destination store.  If --remove-source is supplied the wallet is then moved to the trash of the source store.
+
+In quiet mode this will return 0 if the wallets are copied, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(viper.GetString("remote") == "", "wallet migrate not available with remote wallets")
+		assert(walletMigrateToStore != "", "--to-store is required")
+
+		var err error
+		fromStore := store
+		fromPassphrase := getStorePassphrase()
+		if walletMigrateFromStore != "" {
+			fromStore, err = newStore(walletMigrateFromStore, walletMigrateFromBaseDir, walletMigrateFromPassphrase)
+			errCheck(err, "Failed to access source store")
+			fromPassphrase = walletMigrateFromPassphrase
+		} else {
+			assert(walletMigrateFromBaseDir == "" && walletMigrateFromPassphrase == "", "--from-basedir and --from-storepassphrase require --from-store")
+		}
+		toPassphrase := fromPassphrase
+		if cmd.Flags().Changed("to-storepassphrase") {
+			toPassphrase = walletMigrateToPassphrase
+		}
+		toStore, err := newStore(walletMigrateToStore, walletMigrateToBaseDir, toPassphrase)
+		errCheck(err, "Failed to access destination store")
+		assert(!sameStore(fromStore, toStore), "Source and destination stores are the same")
+
+		wallets := make([][]byte, 0)
+		if viper.GetString("wallet") != "" {
+			data, err := fromStore.RetrieveWallet(viper.GetString("wallet"))
+			errCheck(err, "Failed to access wallet")
+			wallets = append(wallets, data)
+		} else {
+			for data := range fromStore.RetrieveWallets() {
+				wallets = append(wallets, data)
+			}
+			errCheck(storeListError(fromStore), "Failed to list wallets")
+		}
+		assert(len(wallets) > 0, "No wallets to migrate")
+
+		for _, data := range wallets {
+			wallet := &storeRecord{}
+			errCheck(json.Unmarshal(data, wallet), "Failed to read wallet")
+			_, err := toStore.RetrieveWalletByID(wallet.ID)
+			assert(err != nil, fmt.Sprintf("Wallet %s already exists in the destination store", wallet.Name))
+			_, err = toStore.RetrieveWallet(wallet.Name)
+			assert(err != nil, fmt.Sprintf("A wallet named %s already exists in the destination store", wallet.Name))
+
+			accounts, err := migrateWallet(fromStore, toStore, wallet, data)
+			if err != nil {
+				// Do not leave a partial copy of the wallet in the destination store.
+				if removeErr := removeStoreWallet(toStore, wallet.ID); removeErr != nil {
+					outputIf(!quiet, fmt.Sprintf("Failed to remove partial copy of wallet %s from destination store: %v", wallet.Name, removeErr))
+				}
+				die(fmt.Sprintf("Failed to migrate wallet %s: %v", wallet.Name, err))
+			}
+			outputIf(verbose, fmt.Sprintf("Migrated wallet %s with %d accounts", wallet.Name, accounts))
+
+			if walletMigrateRemoveSource {
+				storeLocationProvider, ok := fromStore.(e2wtypes.StoreLocationProvider)
+				assert(ok && fromStore.Name() == "filesystem", "Source store does not allow removal of wallets")
+				tombstone, err := walletToTrash(storeLocationProvider.Location(), wallet.ID, wallet.Name)
+				errCheck(err, fmt.Sprintf("Failed to remove wallet %s from source store", wallet.Name))
+				outputIf(verbose, fmt.Sprintf("Source wallet moved to %s", tombstone.Entry))
+			}
+		}
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+// migrateWallet copies a wallet with its accounts and index between stores, and verifies the copy.
+// It returns the number of accounts copied.
+func migrateWallet(from e2wtypes.Store, to e2wtypes.Store, wallet *storeRecord, data []byte) (int, error) {
+	if err := to.StoreWallet(wallet.ID, wallet.Name, data); err != nil {
+		return 0, errors.Wrap(err, "failed to store wallet")
+	}
+
+	accounts := make(map[uuid.UUID][]byte)
+	for accountData := range from.RetrieveAccounts(wallet.ID) {
+		account := &storeRecord{}
+		if err := json.Unmarshal(accountData, account); err != nil {
+			return 0, errors.Wrap(err, "failed to read account")
+		}
+		if err := to.StoreAccount(wallet.ID, account.ID, accountData); err != nil {
+			return 0, errors.Wrap(err, fmt.Sprintf("failed to store account %s", account.Name))
+		}
+		accounts[account.ID] = accountData
+	}
+
+	// Wallets without accounts may not have an index.
+	index, err := from.RetrieveAccountsIndex(wallet.ID)
+	if err == nil {
+		if err := to.StoreAccountsIndex(wallet.ID, index); err != nil {
+			return 0, errors.Wrap(err, "failed to store accounts index")
+		}
+	}
+
+	// Verify the destination.
+	storedData, err := to.RetrieveWalletByID(wallet.ID)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve migrated wallet")
+	}
+	if !bytes.Equal(storedData, data) {
+		return 0, errors.New("migrated wallet does not match source")
+	}
+	for accountID, accountData := range accounts {
+		storedData, err := to.RetrieveAccount(wallet.ID, accountID)
+		if err != nil {
+			return 0, errors.Wrap(err, fmt.Sprintf("failed to retrieve migrated account %s", accountID))
+		}
+		if !bytes.Equal(storedData, accountData) {
+			return 0, fmt.Errorf("migrated account %s does not match source", accountID)
+		}
+	}
+	if index != nil {
+		storedIndex, err := to.RetrieveAccountsIndex(wallet.ID)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to retrieve migrated accounts index")
+		}
+		if !bytes.Equal(storedIndex, index) {
+			return 0, errors.New("migrated accounts index does not match source")
+		}
+	}
+
+	return len(accounts), nil
+}
+
+// sameStore returns true if the two stores refer to the same location.
+func sameStore(a e2wtypes.Store, b e2wtypes.Store) bool {
+	if a.Name() != b.Name() {
+		return false
+	}
+	aLocationProvider, aOK := a.(e2wtypes.StoreLocationProvider)
+	bLocationProvider, bOK := b.(e2wtypes.StoreLocationProvider)
+	if !aOK || !bOK {
+		return false
+	}
+	return aLocationProvider.Location() == bLocationProvider.Location()
+}
+
+func init() {
+	walletCmd.AddCommand(walletMigrateCmd)
+	walletFlags(walletMigrateCmd)
+	walletMigrateCmd.Flags().StringVar(&walletMigrateFromStore, "from-store", "", "Store from which to copy wallets (defaults to --store)")
+	walletMigrateCmd.Flags().StringVar(&walletMigrateFromBaseDir, "from-basedir", "", "Base directory of the source store, if filesystem")
+	walletMigrateCmd.Flags().StringVar(&walletMigrateFromPassphrase, "from-storepassphrase", "", "Passphrase for the source store (if applicable)")
+	walletMigrateCmd.Flags().StringVar(&walletMigrateToStore, "to-store", "", "Store to which to copy wallets")
+	walletMigrateCmd.Flags().StringVar(&walletMigrateToBaseDir, "to-basedir", "", "Base directory of the destination store, if filesystem")
+	walletMigrateCmd.Flags().StringVar(&walletMigrateToPassphrase, "to-storepassphrase", "", "Passphrase for the destination store (defaults to the passphrase for the source store)")
+	walletMigrateCmd.Flags().BoolVar(&walletMigrateRemoveSource, "remove-source", false, "Move wallets to the trash of the source store once they have been copied")
+}
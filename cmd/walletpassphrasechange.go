@@ -0,0 +1,69 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var walletPassphraseChangeCmd = &cobra.Command{
+	Use:   "change",
+	Short: "Change the passphrase of a wallet",
+	Long: `Change the passphrase of a hierarchical deterministic wallet.  For example:
+
+    ethdo wallet passphrase change --wallet=primary --walletpassphrase="my old secret" --newpassphrase="my new secret"
+
+In quiet mode this will return 0 if the passphrase has been changed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(!remote, "wallet passphrase change not available with remote wallets")
+		assert(viper.GetString("wallet") != "", "--wallet is required")
+		assert(getNewPassphrase() != "", "--newpassphrase is required")
+
+		wallet, err := walletFromPath(ctx, viper.GetString("wallet"))
+		errCheck(err, "Failed to access wallet")
+		assert(wallet.Type() == "hierarchical deterministic", fmt.Sprintf("wallets of type %q do not have a passphrase", wallet.Type()))
+		storeProvider, ok := wallet.(e2wtypes.StoreProvider)
+		assert(ok, "Cannot obtain store for the wallet")
+		walletStore := storeProvider.Store()
+
+		original, err := walletStore.RetrieveWalletByID(wallet.ID())
+		errCheck(err, "Failed to retrieve wallet")
+		update, err := reencryptRecord(original, []string{getWalletPassphrase()}, getNewPassphrase(), nil)
+		errCheck(err, "Failed to change passphrase for wallet")
+
+		if err := walletStore.StoreWallet(wallet.ID(), wallet.Name(), update); err != nil {
+			if rollbackErr := walletStore.StoreWallet(wallet.ID(), wallet.Name(), original); rollbackErr != nil {
+				outputIf(!quiet, fmt.Sprintf("Failed to roll back wallet: %v", rollbackErr))
+			}
+			die(fmt.Sprintf("Failed to store wallet: %v", err))
+		}
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	walletPassphraseCmd.AddCommand(walletPassphraseChangeCmd)
+	walletFlags(walletPassphraseChangeCmd)
+	newPassphraseFlags(walletPassphraseChangeCmd)
+}
@@ -0,0 +1,85 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var walletPurgeOlderThan time.Duration
+var walletPurgeYes bool
+
+var walletPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove deleted wallets",
+	Long: `Permanently remove wallets that were deleted with "wallet delete".  For example:
+
+    ethdo wallet purge --wallet=primary --older-than=720h
+
+If --wallet is not supplied all deleted wallets are removed.  --older-than only removes wallets that were deleted at least that long ago.  The name of the wallet, or "all" if --wallet is not supplied, must be typed to confirm the removal unless --yes is supplied.
+
+In quiet mode this will return 0 if the wallets have been removed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(viper.GetString("remote") == "", "wallet purge not available with remote wallets")
+		assert(walletPurgeOlderThan >= 0, "--older-than cannot be negative")
+
+		location, err := defaultStoreLocation()
+		errCheck(err, "Failed to obtain location of the store")
+		tombstones, err := walletTombstones(location)
+		errCheck(err, "Failed to obtain deleted wallets")
+
+		cutoff := time.Now().Add(-walletPurgeOlderThan)
+		purgeable := make([]*walletTombstone, 0)
+		for _, tombstone := range tombstones {
+			if viper.GetString("wallet") != "" && tombstone.Name != viper.GetString("wallet") {
+				continue
+			}
+			if tombstone.Deleted.After(cutoff) {
+				continue
+			}
+			purgeable = append(purgeable, tombstone)
+		}
+		if len(purgeable) == 0 {
+			outputIf(verbose, "No deleted wallets to remove")
+			os.Exit(_exitSuccess)
+		}
+
+		if !walletPurgeYes {
+			expected := "all"
+			if viper.GetString("wallet") != "" {
+				expected = viper.GetString("wallet")
+			}
+			assert(confirmInput(fmt.Sprintf("Type %q to confirm permanent removal of %d deleted wallet(s): ", expected, len(purgeable)), expected), "Removal not confirmed")
+		}
+
+		for _, tombstone := range purgeable {
+			errCheck(os.RemoveAll(tombstone.Entry), fmt.Sprintf("Failed to remove deleted wallet %s", tombstone.Name))
+			outputIf(verbose, fmt.Sprintf("Removed deleted wallet %s (%s)", tombstone.Name, tombstone.ID))
+		}
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(walletPurgeCmd)
+	walletFlags(walletPurgeCmd)
+	walletPurgeCmd.Flags().DurationVar(&walletPurgeOlderThan, "older-than", 0, "Only remove wallets deleted at least this long ago")
+	walletPurgeCmd.Flags().BoolVar(&walletPurgeYes, "yes", false, "Do not ask for confirmation")
+}
@@ -0,0 +1,85 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+)
+
+var walletRestoreList bool
+
+var walletRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a deleted wallet",
+	Long: `Restore a wallet that was deleted with "wallet delete".  For example:
+
+    ethdo wallet restore --wallet=primary
+
+If the wallet has been deleted more than once the most recently deleted copy is restored.  --list shows the wallets that can be restored.
+
+In quiet mode this will return 0 if the wallet has been restored, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		assert(viper.GetString("remote") == "", "wallet restore not available with remote wallets")
+		assert(walletRestoreList || viper.GetString("wallet") != "", "--wallet is required")
+
+		location, err := defaultStoreLocation()
+		errCheck(err, "Failed to obtain location of the store")
+		tombstones, err := walletTombstones(location)
+		errCheck(err, "Failed to obtain deleted wallets")
+
+		if walletRestoreList {
+			for _, tombstone := range tombstones {
+				outputIf(!quiet, fmt.Sprintf("%s\t%s\t%s", tombstone.Name, tombstone.ID, tombstone.Deleted.Format("2006-01-02 15:04:05 MST")))
+			}
+			os.Exit(_exitSuccess)
+		}
+
+		var found bool
+		var entry string
+		var walletID string
+		for _, tombstone := range tombstones {
+			if tombstone.Name == viper.GetString("wallet") {
+				// Tombstones are in deletion order, so the last match is the most recent.
+				found = true
+				entry = tombstone.Entry
+				walletID = tombstone.ID.String()
+			}
+		}
+		assert(found, "No deleted wallet with that name")
+
+		_, err = e2wallet.OpenWallet(viper.GetString("wallet"))
+		assert(err != nil, "A wallet with that name already exists")
+		walletLocation := filepath.Join(location, walletID)
+		_, err = os.Stat(walletLocation)
+		assert(os.IsNotExist(err), "A wallet with that ID already exists")
+
+		errCheck(os.Rename(filepath.Join(entry, walletID), walletLocation), "Failed to restore wallet")
+		errCheck(os.RemoveAll(entry), "Failed to remove trash entry")
+		outputIf(verbose, fmt.Sprintf("Restored wallet %s", walletID))
+
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(walletRestoreCmd)
+	walletFlags(walletRestoreCmd)
+	walletRestoreCmd.Flags().BoolVar(&walletRestoreList, "list", false, "List the wallets that can be restored")
+}
@@ -0,0 +1,82 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc"
+)
+
+var walletUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock a remote wallet",
+	Long: `Unlock a remote wallet on each remote wallet daemon.  For example:
+
+    ethdo wallet unlock --wallet=primary --walletpassphrase="my wallet secret" --remote=dirk1.example.com:13141,dirk2.example.com:13141
+
+An unlocked wallet can create new accounts.
+
+In quiet mode this will return 0 if the wallet is unlocked on all remote wallet daemons, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
+		defer cancel()
+
+		assert(remote, "wallet unlock is only available with remote wallets")
+		assert(viper.GetString("wallet") != "", "--wallet is required")
+		assert(getWalletPassphrase() != "", "--walletpassphrase is required")
+
+		credentials, err := remoteCredentials(ctx)
+		errCheck(err, "Failed to obtain remote credentials")
+
+		failed := false
+		for _, address := range remoteAddresses() {
+			err := withRemoteConnection(credentials, address, func(ctx context.Context, conn *grpc.ClientConn) error {
+				resp, err := pb.NewWalletManagerClient(conn).Unlock(ctx, &pb.UnlockWalletRequest{
+					Wallet:     viper.GetString("wallet"),
+					Passphrase: []byte(getWalletPassphrase()),
+				})
+				if err != nil {
+					return errors.Wrap(err, "failed to unlock wallet")
+				}
+				if resp.State != pb.ResponseState_SUCCEEDED {
+					return fmt.Errorf("request to unlock wallet %s", responseStateDescription(resp.State))
+				}
+				return nil
+			})
+			if err != nil {
+				outputIf(!quiet, fmt.Sprintf("%s: %v", address, err))
+				failed = true
+				continue
+			}
+			outputIf(verbose, fmt.Sprintf("%s: wallet unlocked", address))
+		}
+
+		if failed {
+			os.Exit(_exitFailure)
+		}
+		os.Exit(_exitSuccess)
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(walletUnlockCmd)
+	walletFlags(walletUnlockCmd)
+}
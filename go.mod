@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/OneOfOne/xxhash v1.2.5 // indirect
+	github.com/aws/aws-sdk-go v1.35.7
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
 	github.com/gogo/protobuf v1.3.1
 	github.com/google/uuid v1.1.2
@@ -37,9 +38,10 @@ require (
 	github.com/wealdtech/go-eth2-wallet-hd/v2 v2.5.1
 	github.com/wealdtech/go-eth2-wallet-nd/v2 v2.3.1
 	github.com/wealdtech/go-eth2-wallet-store-filesystem v1.16.1
-	github.com/wealdtech/go-eth2-wallet-store-s3 v1.9.0
 	github.com/wealdtech/go-eth2-wallet-types/v2 v2.8.0
+	github.com/wealdtech/go-indexer v1.0.0
 	github.com/wealdtech/go-string2eth v1.1.0
+	golang.org/x/crypto v0.0.0-20201012173705-84dcc777aaee
 	golang.org/x/text v0.3.3
 	google.golang.org/grpc v1.33.0
 	gopkg.in/ini.v1 v1.62.0 // indirect
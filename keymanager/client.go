@@ -0,0 +1,239 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Keystore is a keystore held by a validator client.
+type Keystore struct {
+	ValidatingPubKey string `json:"validating_pubkey"`
+	DerivationPath   string `json:"derivation_path,omitempty"`
+	ReadOnly         bool   `json:"readonly,omitempty"`
+}
+
+// Status is the result of importing or deleting a single keystore.
+type Status struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Client talks to the keymanager API of a validator client.
+type Client struct {
+	base   *url.URL
+	token  string
+	client *http.Client
+}
+
+// NewClient creates a client for the keymanager API at the given address, authenticating with the given bearer token.
+func NewClient(address string, token string) (*Client, error) {
+	if address == "" {
+		return nil, errors.New("no keymanager address supplied")
+	}
+	if !strings.Contains(address, "://") {
+		address = fmt.Sprintf("http://%s", address)
+	}
+	base, err := url.Parse(strings.TrimSuffix(address, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid keymanager address")
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported keymanager address scheme %q", base.Scheme)
+	}
+	return &Client{
+		base:   base,
+		token:  token,
+		client: &http.Client{},
+	}, nil
+}
+
+// ListKeystores lists the keystores held by the validator client.
+func (c *Client) ListKeystores(ctx context.Context) ([]*Keystore, error) {
+	res := &struct {
+		Data []*Keystore `json:"data"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/eth/v1/keystores", nil, res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// ImportKeystores imports EIP-2335 keystores, with their passwords and optional EIP-3076 slashing protection data,
+// in to the validator client.  It returns the status of each keystore, in the order supplied.
+func (c *Client) ImportKeystores(ctx context.Context, keystores []string, passwords []string, slashingProtection string) ([]*Status, error) {
+	if len(keystores) != len(passwords) {
+		return nil, errors.New("number of keystores and passwords differ")
+	}
+	req := &struct {
+		Keystores          []string `json:"keystores"`
+		Passwords          []string `json:"passwords"`
+		SlashingProtection string   `json:"slashing_protection,omitempty"`
+	}{
+		Keystores:          keystores,
+		Passwords:          passwords,
+		SlashingProtection: slashingProtection,
+	}
+	res := &struct {
+		Data []*Status `json:"data"`
+	}{}
+	if err := c.do(ctx, http.MethodPost, "/eth/v1/keystores", req, res); err != nil {
+		return nil, err
+	}
+	if len(res.Data) != len(keystores) {
+		return nil, fmt.Errorf("keymanager returned %d statuses for %d keystores", len(res.Data), len(keystores))
+	}
+	return res.Data, nil
+}
+
+// DeleteKeystores deletes the keystores for the given public keys from the validator client.  It returns the status
+// of each key, in the order supplied, along with the EIP-3076 slashing protection data for the keys.  The slashing
+// protection data is returned even if the statuses are invalid, as the keys may already have been deleted.
+func (c *Client) DeleteKeystores(ctx context.Context, pubKeys []string) ([]*Status, string, error) {
+	req := &struct {
+		PubKeys []string `json:"pubkeys"`
+	}{
+		PubKeys: pubKeys,
+	}
+	res := &struct {
+		Data               []*Status `json:"data"`
+		SlashingProtection string    `json:"slashing_protection"`
+	}{}
+	if err := c.do(ctx, http.MethodDelete, "/eth/v1/keystores", req, res); err != nil {
+		return nil, "", err
+	}
+	if len(res.Data) != len(pubKeys) {
+		return nil, res.SlashingProtection, fmt.Errorf("keymanager returned %d statuses for %d keys", len(res.Data), len(pubKeys))
+	}
+	return res.Data, res.SlashingProtection, nil
+}
+
+// FeeRecipient obtains the fee recipient for the given public key.
+func (c *Client) FeeRecipient(ctx context.Context, pubKey string) (string, error) {
+	res := &struct {
+		Data struct {
+			EthAddress string `json:"ethaddress"`
+		} `json:"data"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, validatorPath(pubKey, "feerecipient"), nil, res); err != nil {
+		return "", err
+	}
+	return res.Data.EthAddress, nil
+}
+
+// SetFeeRecipient sets the fee recipient for the given public key.
+func (c *Client) SetFeeRecipient(ctx context.Context, pubKey string, address string) error {
+	req := &struct {
+		EthAddress string `json:"ethaddress"`
+	}{
+		EthAddress: address,
+	}
+	return c.do(ctx, http.MethodPost, validatorPath(pubKey, "feerecipient"), req, nil)
+}
+
+// DeleteFeeRecipient removes the fee recipient for the given public key, returning it to the client's default.
+func (c *Client) DeleteFeeRecipient(ctx context.Context, pubKey string) error {
+	return c.do(ctx, http.MethodDelete, validatorPath(pubKey, "feerecipient"), nil, nil)
+}
+
+// Graffiti obtains the graffiti for the given public key.
+func (c *Client) Graffiti(ctx context.Context, pubKey string) (string, error) {
+	res := &struct {
+		Data struct {
+			Graffiti string `json:"graffiti"`
+		} `json:"data"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, validatorPath(pubKey, "graffiti"), nil, res); err != nil {
+		return "", err
+	}
+	return res.Data.Graffiti, nil
+}
+
+// SetGraffiti sets the graffiti for the given public key.
+func (c *Client) SetGraffiti(ctx context.Context, pubKey string, graffiti string) error {
+	req := &struct {
+		Graffiti string `json:"graffiti"`
+	}{
+		Graffiti: graffiti,
+	}
+	return c.do(ctx, http.MethodPost, validatorPath(pubKey, "graffiti"), req, nil)
+}
+
+// DeleteGraffiti removes the graffiti for the given public key, returning it to the client's default.
+func (c *Client) DeleteGraffiti(ctx context.Context, pubKey string) error {
+	return c.do(ctx, http.MethodDelete, validatorPath(pubKey, "graffiti"), nil, nil)
+}
+
+func validatorPath(pubKey string, item string) string {
+	return fmt.Sprintf("/eth/v1/validator/%s/%s", url.PathEscape(pubKey), item)
+}
+
+// do carries out a request, decoding the response in to res if supplied.
+func (c *Client) do(ctx context.Context, method string, path string, req interface{}, res interface{}) error {
+	var body io.Reader
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		body = bytes.NewReader(data)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.base.String()+path, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if req != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	httpRes, err := c.client.Do(httpReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to contact keymanager")
+	}
+	defer httpRes.Body.Close()
+	data, err := ioutil.ReadAll(httpRes.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		apiErr := &struct {
+			Message string `json:"message"`
+		}{}
+		if err := json.Unmarshal(data, apiErr); err == nil && apiErr.Message != "" {
+			return fmt.Errorf("keymanager returned %d: %s", httpRes.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("keymanager returned %d", httpRes.StatusCode)
+	}
+	if res != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, res); err != nil {
+			return errors.Wrap(err, "failed to parse response")
+		}
+	}
+	return nil
+}
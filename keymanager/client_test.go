@@ -0,0 +1,264 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+const testGenesisValidatorsRoot = "0x04700007fabc8282644aed6d1c7c9e21d38a03a0c4ba193f3afe428824b3a673"
+
+func newTestServer(t *testing.T) *httptest.Server {
+	stub, err := NewStub("secret")
+	if err != nil {
+		t.Fatalf("failed to create stub: %v", err)
+	}
+	return httptest.NewServer(stub)
+}
+
+func newTestClient(t *testing.T, server *httptest.Server, token string) *Client {
+	client, err := NewClient(server.URL, token)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+// newTestKeystore creates a keystore for a new key, returning the keystore and its public key.
+func newTestKeystore(t *testing.T, password string) (string, string) {
+	privateKey, err := e2types.GenerateBLSPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	crypto, err := keystorev4.New(keystorev4.WithCipher("pbkdf2")).Encrypt(privateKey.Marshal(), password)
+	if err != nil {
+		t.Fatalf("failed to encrypt key: %v", err)
+	}
+	pubKey := privateKey.PublicKey().Marshal()
+	data, err := json.Marshal(map[string]interface{}{
+		"crypto":  crypto,
+		"pubkey":  fmt.Sprintf("%x", pubKey),
+		"version": 4,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate keystore: %v", err)
+	}
+	return string(data), fmt.Sprintf("%#x", pubKey)
+}
+
+func testInterchange(pubKey string) string {
+	return fmt.Sprintf(`{"metadata":{"interchange_format_version":"5","genesis_validators_root":%q},"data":[{"pubkey":%q,"signed_blocks":[{"slot":"81952"}],"signed_attestations":[]}]}`, testGenesisValidatorsRoot, pubKey)
+}
+
+func TestAuthentication(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{
+			name:  "Missing",
+			token: "",
+			err:   "keymanager returned 401: missing bearer token",
+		},
+		{
+			name:  "Bad",
+			token: "wrong",
+			err:   "keymanager returned 403: invalid bearer token",
+		},
+		{
+			name:  "Good",
+			token: "secret",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newTestClient(t, server, test.token).ListKeystores(ctx)
+			switch {
+			case test.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.err != "" && err == nil:
+				t.Fatalf("expected error %q", test.err)
+			case test.err != "" && err.Error() != test.err:
+				t.Fatalf("expected error %q, got %q", test.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestImportDelete(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	client := newTestClient(t, server, "secret")
+	ctx := context.Background()
+
+	keystore, pubKey := newTestKeystore(t, "pass")
+	statuses, err := client.ImportKeystores(ctx, []string{keystore}, []string{"pass"}, testInterchange(pubKey))
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if statuses[0].Status != "imported" {
+		t.Fatalf("expected imported, got %s", statuses[0].Status)
+	}
+
+	// Importing again is a duplicate; a bad password is an error.
+	badKeystore, _ := newTestKeystore(t, "pass")
+	statuses, err = client.ImportKeystores(ctx, []string{keystore, badKeystore}, []string{"pass", "wrong"}, "")
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if statuses[0].Status != "duplicate" {
+		t.Fatalf("expected duplicate, got %s", statuses[0].Status)
+	}
+	if statuses[1].Status != "error" {
+		t.Fatalf("expected error, got %s", statuses[1].Status)
+	}
+
+	keystores, err := client.ListKeystores(ctx)
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(keystores) != 1 || keystores[0].ValidatingPubKey != pubKey {
+		t.Fatalf("unexpected keystores %v", keystores)
+	}
+
+	unknown := fmt.Sprintf("%#x", make([]byte, 48))
+	statuses, slashingProtection, err := client.DeleteKeystores(ctx, []string{pubKey, unknown})
+	if err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if statuses[0].Status != "deleted" {
+		t.Fatalf("expected deleted, got %s", statuses[0].Status)
+	}
+	if statuses[1].Status != "not_found" {
+		t.Fatalf("expected not_found, got %s", statuses[1].Status)
+	}
+	if !strings.Contains(slashingProtection, `"slot":"81952"`) || !strings.Contains(slashingProtection, testGenesisValidatorsRoot) {
+		t.Fatalf("imported slashing protection not returned: %s", slashingProtection)
+	}
+
+	// Deleting again finds the key inactive, but still returns its slashing protection.
+	statuses, slashingProtection, err = client.DeleteKeystores(ctx, []string{pubKey})
+	if err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if statuses[0].Status != "not_active" {
+		t.Fatalf("expected not_active, got %s", statuses[0].Status)
+	}
+	if !strings.Contains(slashingProtection, `"slot":"81952"`) {
+		t.Fatalf("slashing protection not returned for inactive key: %s", slashingProtection)
+	}
+
+	keystores, err = client.ListKeystores(ctx)
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(keystores) != 0 {
+		t.Fatalf("unexpected keystores %v", keystores)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	client := newTestClient(t, server, "secret")
+	ctx := context.Background()
+
+	keystore, pubKey := newTestKeystore(t, "pass")
+	if _, err := client.ImportKeystores(ctx, []string{keystore}, []string{"pass"}, ""); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	address := "0x1111111111111111111111111111111111111111"
+	if err := client.SetFeeRecipient(ctx, pubKey, address); err != nil {
+		t.Fatalf("failed to set fee recipient: %v", err)
+	}
+	feeRecipient, err := client.FeeRecipient(ctx, pubKey)
+	if err != nil {
+		t.Fatalf("failed to obtain fee recipient: %v", err)
+	}
+	if feeRecipient != address {
+		t.Fatalf("expected fee recipient %s, got %s", address, feeRecipient)
+	}
+	if err := client.DeleteFeeRecipient(ctx, pubKey); err != nil {
+		t.Fatalf("failed to delete fee recipient: %v", err)
+	}
+	feeRecipient, err = client.FeeRecipient(ctx, pubKey)
+	if err != nil {
+		t.Fatalf("failed to obtain fee recipient: %v", err)
+	}
+	if feeRecipient == address {
+		t.Fatal("fee recipient not deleted")
+	}
+	if err := client.SetFeeRecipient(ctx, pubKey, "0x11"); err == nil {
+		t.Fatal("invalid fee recipient accepted")
+	}
+
+	if err := client.SetGraffiti(ctx, pubKey, "hello"); err != nil {
+		t.Fatalf("failed to set graffiti: %v", err)
+	}
+	graffiti, err := client.Graffiti(ctx, pubKey)
+	if err != nil {
+		t.Fatalf("failed to obtain graffiti: %v", err)
+	}
+	if graffiti != "hello" {
+		t.Fatalf("expected graffiti hello, got %s", graffiti)
+	}
+	if err := client.DeleteGraffiti(ctx, pubKey); err != nil {
+		t.Fatalf("failed to delete graffiti: %v", err)
+	}
+	graffiti, err = client.Graffiti(ctx, pubKey)
+	if err != nil {
+		t.Fatalf("failed to obtain graffiti: %v", err)
+	}
+	if graffiti != "" {
+		t.Fatalf("graffiti not deleted: %s", graffiti)
+	}
+
+	unknown := fmt.Sprintf("%#x", make([]byte, 48))
+	if _, err := client.Graffiti(ctx, unknown); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected 404 for unknown key, got %v", err)
+	}
+}
+
+func TestDeleteStatusMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":[],"slashing_protection":"{\"data\":[]}"}`)
+	}))
+	defer server.Close()
+	client := newTestClient(t, server, "secret")
+
+	_, slashingProtection, err := client.DeleteKeystores(context.Background(), []string{fmt.Sprintf("%#x", make([]byte, 48))})
+	if err == nil {
+		t.Fatal("expected error for mismatched statuses")
+	}
+	if slashingProtection != `{"data":[]}` {
+		t.Fatalf("slashing protection not returned with error: %q", slashingProtection)
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keymanager
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+const defaultGenesisValidatorsRoot = "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+var ethAddressRegex = regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
+
+// Stub is an in-memory implementation of the keymanager API, for testing clients without a running validator
+// client.  Imported keystores are decrypted to check their passwords, and slashing protection data is held per key
+// so that it is returned when the key is deleted.
+type Stub struct {
+	token string
+
+	mutex                 sync.Mutex
+	keystores             map[string]string
+	slashingProtection    map[string]json.RawMessage
+	genesisValidatorsRoot string
+	feeRecipients         map[string]string
+	graffiti              map[string]string
+}
+
+// NewStub creates a stub keymanager that requires the given bearer token.
+func NewStub(token string) (*Stub, error) {
+	if err := e2types.InitBLS(); err != nil {
+		return nil, err
+	}
+	return &Stub{
+		token:                 token,
+		keystores:             make(map[string]string),
+		slashingProtection:    make(map[string]json.RawMessage),
+		genesisValidatorsRoot: defaultGenesisValidatorsRoot,
+		feeRecipients:         make(map[string]string),
+		graffiti:              make(map[string]string),
+	}, nil
+}
+
+// ServeHTTP handles keymanager API requests.
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		writeStubError(w, http.StatusUnauthorized, "missing bearer token")
+		return
+	}
+	if auth != fmt.Sprintf("Bearer %s", s.token) {
+		writeStubError(w, http.StatusForbidden, "invalid bearer token")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.URL.Path == "/eth/v1/keystores" {
+		switch r.Method {
+		case http.MethodGet:
+			s.listKeystores(w)
+		case http.MethodPost:
+			s.importKeystores(w, r)
+		case http.MethodDelete:
+			s.deleteKeystores(w, r)
+		default:
+			writeStubError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/eth/v1/validator/"), "/")
+	if !strings.HasPrefix(r.URL.Path, "/eth/v1/validator/") || len(parts) != 2 {
+		writeStubError(w, http.StatusNotFound, "not found")
+		return
+	}
+	pubKey := strings.ToLower(parts[0])
+	if _, exists := s.keystores[pubKey]; !exists {
+		writeStubError(w, http.StatusNotFound, "validator not found")
+		return
+	}
+	switch parts[1] {
+	case "feerecipient":
+		s.handleSetting(w, r, s.feeRecipients, pubKey, "ethaddress", "0x0000000000000000000000000000000000000000", func(val string) bool {
+			return ethAddressRegex.MatchString(val)
+		})
+	case "graffiti":
+		s.handleSetting(w, r, s.graffiti, pubKey, "graffiti", "", func(val string) bool {
+			return len(val) <= 32
+		})
+	default:
+		writeStubError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Stub) listKeystores(w http.ResponseWriter) {
+	pubKeys := make([]string, 0, len(s.keystores))
+	for pubKey := range s.keystores {
+		pubKeys = append(pubKeys, pubKey)
+	}
+	sort.Strings(pubKeys)
+	data := make([]*Keystore, len(pubKeys))
+	for i, pubKey := range pubKeys {
+		data[i] = &Keystore{ValidatingPubKey: pubKey}
+	}
+	writeStubJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Stub) importKeystores(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		Keystores          []string `json:"keystores"`
+		Passwords          []string `json:"passwords"`
+		SlashingProtection string   `json:"slashing_protection"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeStubError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	if len(req.Keystores) != len(req.Passwords) {
+		writeStubError(w, http.StatusBadRequest, "number of keystores and passwords differ")
+		return
+	}
+
+	records := make(map[string]json.RawMessage)
+	if req.SlashingProtection != "" {
+		var genesisValidatorsRoot string
+		var err error
+		genesisValidatorsRoot, records, err = parseInterchange(req.SlashingProtection)
+		if err != nil {
+			writeStubError(w, http.StatusBadRequest, fmt.Sprintf("invalid slashing protection data: %v", err))
+			return
+		}
+		if len(s.keystores) > 0 && genesisValidatorsRoot != s.genesisValidatorsRoot {
+			writeStubError(w, http.StatusBadRequest, "slashing protection data is for a different chain")
+			return
+		}
+		s.genesisValidatorsRoot = genesisValidatorsRoot
+	}
+
+	data := make([]*Status, len(req.Keystores))
+	for i := range req.Keystores {
+		pubKey, err := checkKeystore(req.Keystores[i], req.Passwords[i])
+		switch {
+		case err != nil:
+			data[i] = &Status{Status: "error", Message: err.Error()}
+		case s.keystores[pubKey] != "":
+			data[i] = &Status{Status: "duplicate"}
+		default:
+			s.keystores[pubKey] = req.Keystores[i]
+			if record, exists := records[pubKey]; exists {
+				s.slashingProtection[pubKey] = record
+			}
+			data[i] = &Status{Status: "imported"}
+		}
+	}
+	writeStubJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Stub) deleteKeystores(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		PubKeys []string `json:"pubkeys"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeStubError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	data := make([]*Status, len(req.PubKeys))
+	records := make([]json.RawMessage, 0, len(req.PubKeys))
+	for i, pubKey := range req.PubKeys {
+		pubKey = strings.ToLower(pubKey)
+		record, hasRecord := s.slashingProtection[pubKey]
+		if _, exists := s.keystores[pubKey]; exists {
+			delete(s.keystores, pubKey)
+			delete(s.feeRecipients, pubKey)
+			delete(s.graffiti, pubKey)
+			if !hasRecord {
+				// Keep an empty record so that the key is known to have been held here.
+				record = json.RawMessage(fmt.Sprintf(`{"pubkey":%q,"signed_blocks":[],"signed_attestations":[]}`, pubKey))
+				s.slashingProtection[pubKey] = record
+			}
+			data[i] = &Status{Status: "deleted"}
+			records = append(records, record)
+			continue
+		}
+		if hasRecord {
+			data[i] = &Status{Status: "not_active"}
+			records = append(records, record)
+			continue
+		}
+		data[i] = &Status{Status: "not_found"}
+	}
+
+	interchange, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]string{
+			"interchange_format_version": "5",
+			"genesis_validators_root":    s.genesisValidatorsRoot,
+		},
+		"data": records,
+	})
+	if err != nil {
+		writeStubError(w, http.StatusInternalServerError, "failed to generate slashing protection data")
+		return
+	}
+	writeStubJSON(w, http.StatusOK, map[string]interface{}{
+		"data":                data,
+		"slashing_protection": string(interchange),
+	})
+}
+
+// handleSetting handles the get, set and delete requests for a per-validator setting.
+func (s *Stub) handleSetting(w http.ResponseWriter, r *http.Request, settings map[string]string, pubKey string, field string, defaultValue string, valid func(string) bool) {
+	switch r.Method {
+	case http.MethodGet:
+		val, exists := settings[pubKey]
+		if !exists {
+			val = defaultValue
+		}
+		writeStubJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]string{
+				"pubkey": pubKey,
+				field:    val,
+			},
+		})
+	case http.MethodPost:
+		req := make(map[string]string)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeStubError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+		val, exists := req[field]
+		if !exists || !valid(val) {
+			writeStubError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s", field))
+			return
+		}
+		settings[pubKey] = val
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodDelete:
+		delete(settings, pubKey)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeStubError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// checkKeystore decrypts a keystore with its password, returning its public key.
+func checkKeystore(keystore string, password string) (string, error) {
+	info := &struct {
+		Crypto    map[string]interface{} `json:"crypto"`
+		PublicKey string                 `json:"pubkey"`
+		Version   uint                   `json:"version"`
+	}{}
+	if err := json.Unmarshal([]byte(keystore), info); err != nil {
+		return "", fmt.Errorf("invalid keystore: %v", err)
+	}
+	if info.Version != 4 {
+		return "", fmt.Errorf("unsupported keystore version %d", info.Version)
+	}
+	secret, err := keystorev4.New().Decrypt(info.Crypto, password)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt keystore: %v", err)
+	}
+	privateKey, err := e2types.BLSPrivateKeyFromBytes(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %v", err)
+	}
+	pubKey := privateKey.PublicKey().Marshal()
+	if info.PublicKey != "" {
+		declared, err := hex.DecodeString(strings.TrimPrefix(info.PublicKey, "0x"))
+		if err != nil || !bytes.Equal(declared, pubKey) {
+			return "", fmt.Errorf("keystore public key does not match its private key")
+		}
+	}
+	return fmt.Sprintf("%#x", pubKey), nil
+}
+
+// parseInterchange parses EIP-3076 slashing protection data, returning its genesis validators root and its records
+// by public key.
+func parseInterchange(data string) (string, map[string]json.RawMessage, error) {
+	interchange := &struct {
+		Metadata *struct {
+			GenesisValidatorsRoot string `json:"genesis_validators_root"`
+		} `json:"metadata"`
+		Data []json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal([]byte(data), interchange); err != nil {
+		return "", nil, err
+	}
+	if interchange.Metadata == nil || interchange.Metadata.GenesisValidatorsRoot == "" {
+		return "", nil, fmt.Errorf("no genesis validators root")
+	}
+	records := make(map[string]json.RawMessage, len(interchange.Data))
+	for _, record := range interchange.Data {
+		info := &struct {
+			PubKey string `json:"pubkey"`
+		}{}
+		if err := json.Unmarshal(record, info); err != nil {
+			return "", nil, err
+		}
+		records[strings.ToLower(info.PubKey)] = record
+	}
+	return strings.ToLower(interchange.Metadata.GenesisValidatorsRoot), records, nil
+}
+
+func writeStubJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// Nothing useful can be done with an error once the header is written.
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeStubError(w http.ResponseWriter, status int, message string) {
+	writeStubJSON(w, status, map[string]interface{}{
+		"code":    status,
+		"message": message,
+	})
+}
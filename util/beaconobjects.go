@@ -0,0 +1,300 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// BeaconObject is a beacon chain object whose root can be calculated and signed.
+type BeaconObject struct {
+	// Type is the name of the type of the object, for example "voluntary_exit".
+	Type string
+	// DomainType is the domain type with which the object is signed.
+	DomainType e2types.DomainType
+	// Object is the underlying object.
+	Object interface{}
+}
+
+// DepositMessage is the deposit message, as signed by a validator when making a deposit.
+type DepositMessage struct {
+	PublicKey             []byte `ssz-size:"48"`
+	WithdrawalCredentials []byte `ssz-size:"32"`
+	Amount                uint64
+}
+
+// BeaconObjectTypes are the supported types of beacon object.
+var BeaconObjectTypes = []string{
+	"aggregate_and_proof",
+	"attestation_data",
+	"beacon_block",
+	"deposit_message",
+	"randao",
+	"voluntary_exit",
+}
+
+// checkpointJSON is the JSON representation of a checkpoint.
+type checkpointJSON struct {
+	Epoch uint64 `json:"epoch"`
+	Root  string `json:"root"`
+}
+
+// attestationDataJSON is the JSON representation of attestation data.
+type attestationDataJSON struct {
+	Slot            uint64          `json:"slot"`
+	Index           uint64          `json:"index"`
+	BeaconBlockRoot string          `json:"beacon_block_root"`
+	Source          *checkpointJSON `json:"source"`
+	Target          *checkpointJSON `json:"target"`
+}
+
+// attestationJSON is the JSON representation of an attestation.
+type attestationJSON struct {
+	AggregationBits string               `json:"aggregation_bits"`
+	Data            *attestationDataJSON `json:"data"`
+	Signature       string               `json:"signature"`
+}
+
+// aggregateAndProofJSON is the JSON representation of an aggregate and proof.
+type aggregateAndProofJSON struct {
+	AggregatorIndex uint64           `json:"aggregator_index"`
+	Aggregate       *attestationJSON `json:"aggregate"`
+	SelectionProof  string           `json:"selection_proof"`
+}
+
+// beaconBlockJSON is the JSON representation of a beacon block.
+// The body is represented by its root, as that is all that is required to sign the block.
+type beaconBlockJSON struct {
+	Slot          uint64 `json:"slot"`
+	ProposerIndex uint64 `json:"proposer_index"`
+	ParentRoot    string `json:"parent_root"`
+	StateRoot     string `json:"state_root"`
+	BodyRoot      string `json:"body_root"`
+}
+
+// depositMessageJSON is the JSON representation of a deposit message.
+type depositMessageJSON struct {
+	PublicKey             string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                uint64 `json:"amount"`
+}
+
+// randaoJSON is the JSON representation of a RANDAO reveal.
+type randaoJSON struct {
+	Epoch uint64 `json:"epoch"`
+}
+
+// voluntaryExitJSON is the JSON representation of a voluntary exit.
+type voluntaryExitJSON struct {
+	Epoch          uint64 `json:"epoch"`
+	ValidatorIndex uint64 `json:"validator_index"`
+}
+
+// BeaconObjectFromJSON creates a beacon object of the given type from its JSON representation.
+func BeaconObjectFromJSON(objType string, input []byte) (*BeaconObject, error) {
+	var err error
+	res := &BeaconObject{
+		Type: objType,
+	}
+	switch objType {
+	case "aggregate_and_proof":
+		copy(res.DomainType[:], e2types.DomainAggregateAndProof)
+		res.Object, err = aggregateAndProofFromJSON(input)
+	case "attestation_data":
+		res.DomainType = e2types.DomainBeaconAttester
+		data := &attestationDataJSON{}
+		if err := json.Unmarshal(input, data); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON")
+		}
+		res.Object, err = attestationDataFromJSON(data)
+	case "beacon_block":
+		res.DomainType = e2types.DomainBeaconProposer
+		res.Object, err = beaconBlockFromJSON(input)
+	case "deposit_message":
+		res.DomainType = e2types.DomainDeposit
+		res.Object, err = depositMessageFromJSON(input)
+	case "randao":
+		res.DomainType = e2types.DomainRANDAO
+		data := &randaoJSON{}
+		if err := json.Unmarshal(input, data); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON")
+		}
+		res.Object = data.Epoch
+	case "voluntary_exit":
+		res.DomainType = e2types.DomainVoluntaryExit
+		data := &voluntaryExitJSON{}
+		if err := json.Unmarshal(input, data); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON")
+		}
+		res.Object = &ethpb.VoluntaryExit{
+			Epoch:          data.Epoch,
+			ValidatorIndex: data.ValidatorIndex,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported object type %q", objType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Root calculates the hash tree root of the object.
+func (o *BeaconObject) Root() ([32]byte, error) {
+	return ssz.HashTreeRoot(o.Object)
+}
+
+func aggregateAndProofFromJSON(input []byte) (*ethpb.AggregateAttestationAndProof, error) {
+	data := &aggregateAndProofJSON{}
+	if err := json.Unmarshal(input, data); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON")
+	}
+	if data.Aggregate == nil {
+		return nil, errors.New("aggregate missing")
+	}
+	aggregationBits, err := hexField("aggregation bits", data.Aggregate.AggregationBits, -1)
+	if err != nil {
+		return nil, err
+	}
+	attestationData, err := attestationDataFromJSON(data.Aggregate.Data)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := hexField("signature", data.Aggregate.Signature, 96)
+	if err != nil {
+		return nil, err
+	}
+	selectionProof, err := hexField("selection proof", data.SelectionProof, 96)
+	if err != nil {
+		return nil, err
+	}
+	return &ethpb.AggregateAttestationAndProof{
+		AggregatorIndex: data.AggregatorIndex,
+		Aggregate: &ethpb.Attestation{
+			AggregationBits: aggregationBits,
+			Data:            attestationData,
+			Signature:       signature,
+		},
+		SelectionProof: selectionProof,
+	}, nil
+}
+
+func attestationDataFromJSON(data *attestationDataJSON) (*ethpb.AttestationData, error) {
+	if data == nil {
+		return nil, errors.New("attestation data missing")
+	}
+	beaconBlockRoot, err := hexField("beacon block root", data.BeaconBlockRoot, 32)
+	if err != nil {
+		return nil, err
+	}
+	if data.Source == nil {
+		return nil, errors.New("source missing")
+	}
+	sourceRoot, err := hexField("source root", data.Source.Root, 32)
+	if err != nil {
+		return nil, err
+	}
+	if data.Target == nil {
+		return nil, errors.New("target missing")
+	}
+	targetRoot, err := hexField("target root", data.Target.Root, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &ethpb.AttestationData{
+		Slot:            data.Slot,
+		CommitteeIndex:  data.Index,
+		BeaconBlockRoot: beaconBlockRoot,
+		Source: &ethpb.Checkpoint{
+			Epoch: data.Source.Epoch,
+			Root:  sourceRoot,
+		},
+		Target: &ethpb.Checkpoint{
+			Epoch: data.Target.Epoch,
+			Root:  targetRoot,
+		},
+	}, nil
+}
+
+func beaconBlockFromJSON(input []byte) (*ethpb.BeaconBlockHeader, error) {
+	data := &beaconBlockJSON{}
+	if err := json.Unmarshal(input, data); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON")
+	}
+	parentRoot, err := hexField("parent root", data.ParentRoot, 32)
+	if err != nil {
+		return nil, err
+	}
+	stateRoot, err := hexField("state root", data.StateRoot, 32)
+	if err != nil {
+		return nil, err
+	}
+	bodyRoot, err := hexField("body root", data.BodyRoot, 32)
+	if err != nil {
+		return nil, err
+	}
+	// The root of a block header is the same as the root of its block, so use the header.
+	return &ethpb.BeaconBlockHeader{
+		Slot:          data.Slot,
+		ProposerIndex: data.ProposerIndex,
+		ParentRoot:    parentRoot,
+		StateRoot:     stateRoot,
+		BodyRoot:      bodyRoot,
+	}, nil
+}
+
+func depositMessageFromJSON(input []byte) (*DepositMessage, error) {
+	data := &depositMessageJSON{}
+	if err := json.Unmarshal(input, data); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON")
+	}
+	publicKey, err := hexField("public key", data.PublicKey, 48)
+	if err != nil {
+		return nil, err
+	}
+	withdrawalCredentials, err := hexField("withdrawal credentials", data.WithdrawalCredentials, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &DepositMessage{
+		PublicKey:             publicKey,
+		WithdrawalCredentials: withdrawalCredentials,
+		Amount:                data.Amount,
+	}, nil
+}
+
+// hexField decodes a hex string, ensuring it is of the required length.
+// A length of -1 means that the field can be of any non-zero length.
+func hexField(name string, input string, length int) ([]byte, error) {
+	if input == "" {
+		return nil, fmt.Errorf("%s missing", name)
+	}
+	res, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("%s invalid", name))
+	}
+	if length != -1 && len(res) != length {
+		return nil, fmt.Errorf("%s must be %d bytes", name, length)
+	}
+	return res, nil
+}
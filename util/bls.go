@@ -15,8 +15,10 @@ package util
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	"github.com/herumi/bls-eth-go-binary/bls"
+	"github.com/pkg/errors"
 )
 
 // BLSID turns a uint64 in to a BLS identifier.
@@ -29,3 +31,224 @@ func BLSID(id uint64) *bls.ID {
 	}
 	return &res
 }
+
+// AggregatePublicKeys aggregates public keys in to a single public key.
+func AggregatePublicKeys(pubKeys [][]byte) ([]byte, error) {
+	if len(pubKeys) == 0 {
+		return nil, errors.New("no public keys supplied")
+	}
+	blsPubKeys, err := blsPublicKeys(pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	aggregatePubKey := blsPubKeys[0]
+	for i := 1; i < len(blsPubKeys); i++ {
+		aggregatePubKey.Add(&blsPubKeys[i])
+	}
+	return aggregatePubKey.Serialize(), nil
+}
+
+// FastAggregateVerify verifies an aggregate signature of a single message by multiple public keys.
+func FastAggregateVerify(signature []byte, pubKeys [][]byte, msg []byte) (bool, error) {
+	if len(pubKeys) == 0 {
+		return false, errors.New("no public keys supplied")
+	}
+	var sig bls.Sign
+	if err := sig.Deserialize(signature); err != nil {
+		return false, errors.Wrap(err, "invalid signature")
+	}
+	blsPubKeys, err := blsPublicKeys(pubKeys)
+	if err != nil {
+		return false, err
+	}
+	return sig.FastAggregateVerify(blsPubKeys, msg), nil
+}
+
+// AggregateVerify verifies an aggregate signature of multiple 32-byte messages, each signed by the public key at the same index.
+// All messages must be different.
+func AggregateVerify(signature []byte, pubKeys [][]byte, msgs [][]byte) (bool, error) {
+	if len(pubKeys) == 0 {
+		return false, errors.New("no public keys supplied")
+	}
+	if len(pubKeys) != len(msgs) {
+		return false, errors.New("number of public keys and messages differ")
+	}
+	var sig bls.Sign
+	if err := sig.Deserialize(signature); err != nil {
+		return false, errors.Wrap(err, "invalid signature")
+	}
+	blsPubKeys, err := blsPublicKeys(pubKeys)
+	if err != nil {
+		return false, err
+	}
+	concatenatedMsgs := make([]byte, 0, 32*len(msgs))
+	for i := range msgs {
+		if len(msgs[i]) != 32 {
+			return false, fmt.Errorf("message %d is not 32 bytes", i)
+		}
+		concatenatedMsgs = append(concatenatedMsgs, msgs[i]...)
+	}
+	return sig.AggregateVerify(blsPubKeys, concatenatedMsgs), nil
+}
+
+func blsPublicKeys(pubKeys [][]byte) ([]bls.PublicKey, error) {
+	res := make([]bls.PublicKey, len(pubKeys))
+	for i := range pubKeys {
+		if err := res[i].Deserialize(pubKeys[i]); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid public key %#x", pubKeys[i]))
+		}
+	}
+	return res, nil
+}
+
+// ThresholdShares splits a private key in to shares for the given number of participants, any threshold of which
+// can recreate the key.  It returns the shares indexed by participant ID (starting at 1) and the verification vector.
+func ThresholdShares(privateKey []byte, threshold uint32, participants uint32) (map[uint64][]byte, [][]byte, error) {
+	if threshold < 1 {
+		return nil, nil, errors.New("threshold must be at least 1")
+	}
+	if threshold > participants {
+		return nil, nil, errors.New("threshold cannot be more than the number of participants")
+	}
+	var sec bls.SecretKey
+	if err := sec.Deserialize(privateKey); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid private key")
+	}
+
+	msk := sec.GetMasterSecretKey(int(threshold))
+	mpk := bls.GetMasterPublicKey(msk)
+	verificationVector := make([][]byte, len(mpk))
+	for i := range mpk {
+		verificationVector[i] = mpk[i].Serialize()
+	}
+
+	shares := make(map[uint64][]byte, participants)
+	for id := uint64(1); id <= uint64(participants); id++ {
+		var share bls.SecretKey
+		if err := share.Set(msk, BLSID(id)); err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("failed to create share %d", id))
+		}
+		shares[id] = share.Serialize()
+	}
+
+	return shares, verificationVector, nil
+}
+
+// ThresholdSharePublicKey calculates the public key of the share for a participant from the verification vector.
+func ThresholdSharePublicKey(verificationVector [][]byte, id uint64) ([]byte, error) {
+	mpk, err := blsPublicKeys(verificationVector)
+	if err != nil {
+		return nil, err
+	}
+	if len(mpk) == 0 {
+		return nil, errors.New("empty verification vector")
+	}
+	var pubKey bls.PublicKey
+	if err := pubKey.Set(mpk, BLSID(id)); err != nil {
+		return nil, errors.Wrap(err, "failed to calculate share public key")
+	}
+	return pubKey.Serialize(), nil
+}
+
+// RecoverThresholdKey recovers a private key from shares indexed by participant ID.
+func RecoverThresholdKey(shares map[uint64][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares supplied")
+	}
+	secs := make([]bls.SecretKey, 0, len(shares))
+	ids := make([]bls.ID, 0, len(shares))
+	for id, share := range shares {
+		var sec bls.SecretKey
+		if err := sec.Deserialize(share); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid share %d", id))
+		}
+		secs = append(secs, sec)
+		ids = append(ids, *BLSID(id))
+	}
+	var sec bls.SecretKey
+	if err := sec.Recover(secs, ids); err != nil {
+		return nil, errors.Wrap(err, "failed to recover key")
+	}
+	return sec.Serialize(), nil
+}
+
+// DistributedKeyGeneration simulates a distributed key generation ceremony between the given number of participants
+// using Feldman verifiable secret sharing.  Each participant deals shares of its own random secret to all participants,
+// and each received share is verified against the dealer's verification vector.  The composite private key is never
+// created.  It returns the combined shares indexed by participant ID (starting at 1) and the composite verification
+// vector, the first element of which is the composite public key.
+func DistributedKeyGeneration(threshold uint32, participants uint32) (map[uint64][]byte, [][]byte, error) {
+	if threshold < 1 {
+		return nil, nil, errors.New("threshold must be at least 1")
+	}
+	if threshold > participants {
+		return nil, nil, errors.New("threshold cannot be more than the number of participants")
+	}
+
+	shares := make(map[uint64]*bls.SecretKey, participants)
+	mpk := make([]bls.PublicKey, threshold)
+	for dealer := uint64(1); dealer <= uint64(participants); dealer++ {
+		var sec bls.SecretKey
+		sec.SetByCSPRNG()
+		msk := sec.GetMasterSecretKey(int(threshold))
+		dealerMPK := bls.GetMasterPublicKey(msk)
+		for id := uint64(1); id <= uint64(participants); id++ {
+			var share bls.SecretKey
+			if err := share.Set(msk, BLSID(id)); err != nil {
+				return nil, nil, errors.Wrap(err, fmt.Sprintf("failed to create share %d of dealer %d", id, dealer))
+			}
+			// The recipient verifies the share against the dealer's verification vector.
+			var expectedPubKey bls.PublicKey
+			if err := expectedPubKey.Set(dealerMPK, BLSID(id)); err != nil {
+				return nil, nil, errors.Wrap(err, "failed to calculate share public key")
+			}
+			if !share.GetPublicKey().IsEqual(&expectedPubKey) {
+				return nil, nil, fmt.Errorf("share %d of dealer %d does not match verification vector", id, dealer)
+			}
+			if _, exists := shares[id]; exists {
+				shares[id].Add(&share)
+			} else {
+				shares[id] = &share
+			}
+		}
+		for i := range dealerMPK {
+			if dealer == 1 {
+				mpk[i] = dealerMPK[i]
+			} else {
+				mpk[i].Add(&dealerMPK[i])
+			}
+		}
+	}
+
+	res := make(map[uint64][]byte, participants)
+	for id, share := range shares {
+		res[id] = share.Serialize()
+	}
+	verificationVector := make([][]byte, len(mpk))
+	for i := range mpk {
+		verificationVector[i] = mpk[i].Serialize()
+	}
+	return res, verificationVector, nil
+}
+
+// RecoverThresholdSignature recovers a composite signature from threshold signatures indexed by participant ID.
+func RecoverThresholdSignature(signatures map[uint64][]byte) ([]byte, error) {
+	if len(signatures) == 0 {
+		return nil, errors.New("no signatures supplied")
+	}
+	sigs := make([]bls.Sign, 0, len(signatures))
+	ids := make([]bls.ID, 0, len(signatures))
+	for id, signature := range signatures {
+		var sig bls.Sign
+		if err := sig.Deserialize(signature); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid signature %d", id))
+		}
+		sigs = append(sigs, sig)
+		ids = append(ids, *BLSID(id))
+	}
+	var compositeSig bls.Sign
+	if err := compositeSig.Recover(sigs, ids); err != nil {
+		return nil, errors.Wrap(err, "failed to recover signature")
+	}
+	return compositeSig.Serialize(), nil
+}
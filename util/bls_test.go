@@ -0,0 +1,382 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/herumi/bls-eth-go-binary/bls"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// testSigners creates a number of random secret keys and their public keys.
+func testSigners(t *testing.T, count int) ([]*bls.SecretKey, [][]byte) {
+	if err := e2types.InitBLS(); err != nil {
+		t.Fatal(err)
+	}
+	secretKeys := make([]*bls.SecretKey, count)
+	pubKeys := make([][]byte, count)
+	for i := range secretKeys {
+		secretKeys[i] = &bls.SecretKey{}
+		secretKeys[i].SetByCSPRNG()
+		pubKeys[i] = secretKeys[i].GetPublicKey().Serialize()
+	}
+	return secretKeys, pubKeys
+}
+
+// testAggregateSignature signs each message with the secret key at the same index and aggregates the signatures.
+func testAggregateSignature(secretKeys []*bls.SecretKey, msgs [][]byte) []byte {
+	sigs := make([]bls.Sign, len(secretKeys))
+	for i := range secretKeys {
+		sigs[i] = *secretKeys[i].SignByte(msgs[i])
+	}
+	var sig bls.Sign
+	sig.Aggregate(sigs)
+	return sig.Serialize()
+}
+
+func TestAggregatePublicKeys(t *testing.T) {
+	secretKeys, pubKeys := testSigners(t, 3)
+	msg := bytes.Repeat([]byte{0x01}, 32)
+	signature := testAggregateSignature(secretKeys, [][]byte{msg, msg, msg})
+
+	aggregatePubKey, err := AggregatePublicKeys(pubKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pubKey bls.PublicKey
+	if err := pubKey.Deserialize(aggregatePubKey); err != nil {
+		t.Fatal(err)
+	}
+	var sig bls.Sign
+	if err := sig.Deserialize(signature); err != nil {
+		t.Fatal(err)
+	}
+	if !sig.VerifyByte(&pubKey, msg) {
+		t.Fatal("aggregate signature did not verify against aggregate public key")
+	}
+
+	// Aggregating the public keys of only some of the signers gives a key that does not verify.
+	partialPubKey, err := AggregatePublicKeys(pubKeys[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pubKey.Deserialize(partialPubKey); err != nil {
+		t.Fatal(err)
+	}
+	if sig.VerifyByte(&pubKey, msg) {
+		t.Fatal("aggregate signature verified against aggregate public key with a missing signer")
+	}
+
+	if _, err := AggregatePublicKeys(nil); err == nil {
+		t.Fatal("expected error aggregating no public keys")
+	}
+	if _, err := AggregatePublicKeys([][]byte{pubKeys[0], {0x01}}); err == nil {
+		t.Fatal("expected error aggregating an invalid public key")
+	}
+}
+
+func TestFastAggregateVerify(t *testing.T) {
+	secretKeys, pubKeys := testSigners(t, 3)
+	msg := bytes.Repeat([]byte{0x01}, 32)
+
+	tests := []struct {
+		name      string
+		signature []byte
+		pubKeys   [][]byte
+		msg       []byte
+		verified  bool
+		err       bool
+	}{
+		{
+			name:      "Valid",
+			signature: testAggregateSignature(secretKeys, [][]byte{msg, msg, msg}),
+			pubKeys:   pubKeys,
+			msg:       msg,
+			verified:  true,
+		},
+		{
+			name:      "MissingSigner",
+			signature: testAggregateSignature(secretKeys[:2], [][]byte{msg, msg}),
+			pubKeys:   pubKeys,
+			msg:       msg,
+		},
+		{
+			name:      "DifferentMessage",
+			signature: testAggregateSignature(secretKeys, [][]byte{msg, msg, msg}),
+			pubKeys:   pubKeys,
+			msg:       bytes.Repeat([]byte{0x02}, 32),
+		},
+		{
+			name:      "NoPublicKeys",
+			signature: testAggregateSignature(secretKeys, [][]byte{msg, msg, msg}),
+			msg:       msg,
+			err:       true,
+		},
+		{
+			name:      "InvalidSignature",
+			signature: []byte{0x01},
+			pubKeys:   pubKeys,
+			msg:       msg,
+			err:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verified, err := FastAggregateVerify(test.signature, test.pubKeys, test.msg)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if verified != test.verified {
+				t.Fatalf("expected verified %t, got %t", test.verified, verified)
+			}
+		})
+	}
+}
+
+func TestAggregateVerify(t *testing.T) {
+	secretKeys, pubKeys := testSigners(t, 3)
+	msgs := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+		bytes.Repeat([]byte{0x03}, 32),
+	}
+	signature := testAggregateSignature(secretKeys, msgs)
+
+	tests := []struct {
+		name      string
+		signature []byte
+		pubKeys   [][]byte
+		msgs      [][]byte
+		verified  bool
+		err       bool
+	}{
+		{
+			name:      "Valid",
+			signature: signature,
+			pubKeys:   pubKeys,
+			msgs:      msgs,
+			verified:  true,
+		},
+		{
+			name:      "MissingSigner",
+			signature: testAggregateSignature(secretKeys[:2], msgs[:2]),
+			pubKeys:   pubKeys,
+			msgs:      msgs,
+		},
+		{
+			name:      "MessagesWrongOrder",
+			signature: signature,
+			pubKeys:   pubKeys,
+			msgs:      [][]byte{msgs[1], msgs[0], msgs[2]},
+		},
+		{
+			name:      "MessageNot32Bytes",
+			signature: signature,
+			pubKeys:   pubKeys,
+			msgs:      [][]byte{msgs[0], msgs[1], msgs[2][:31]},
+			err:       true,
+		},
+		{
+			name:      "MessageCountMismatch",
+			signature: signature,
+			pubKeys:   pubKeys,
+			msgs:      msgs[:2],
+			err:       true,
+		},
+		{
+			name:      "NoPublicKeys",
+			signature: signature,
+			err:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verified, err := AggregateVerify(test.signature, test.pubKeys, test.msgs)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if verified != test.verified {
+				t.Fatalf("expected verified %t, got %t", test.verified, verified)
+			}
+		})
+	}
+}
+
+// testThresholdSign signs a message with each of the supplied shares, returning the signatures indexed by participant ID.
+func testThresholdSign(t *testing.T, shares map[uint64][]byte, ids []byte, msg []byte) map[uint64][]byte {
+	signatures := make(map[uint64][]byte, len(ids))
+	for _, id := range ids {
+		var sec bls.SecretKey
+		if err := sec.Deserialize(shares[uint64(id)]); err != nil {
+			t.Fatalf("invalid share %d: %v", id, err)
+		}
+		signatures[uint64(id)] = sec.SignByte(msg).Serialize()
+	}
+	return signatures
+}
+
+// testVerify verifies a signature of a message against a public key.
+func testVerify(t *testing.T, signature []byte, pubKey []byte, msg []byte) bool {
+	var sig bls.Sign
+	if err := sig.Deserialize(signature); err != nil {
+		t.Fatalf("invalid signature: %v", err)
+	}
+	var pk bls.PublicKey
+	if err := pk.Deserialize(pubKey); err != nil {
+		t.Fatalf("invalid public key: %v", err)
+	}
+	return sig.VerifyByte(&pk, msg)
+}
+
+func TestDistributedKeyGeneration(t *testing.T) {
+	if err := e2types.InitBLS(); err != nil {
+		t.Fatal(err)
+	}
+	msg := bytes.Repeat([]byte{0x01}, 32)
+
+	for _, test := range []struct {
+		threshold    uint32
+		participants uint32
+	}{
+		{threshold: 1, participants: 1},
+		{threshold: 2, participants: 3},
+		{threshold: 3, participants: 5},
+	} {
+		shares, verificationVector, err := DistributedKeyGeneration(test.threshold, test.participants)
+		if err != nil {
+			t.Fatalf("%d of %d: failed to run distributed key generation: %v", test.threshold, test.participants, err)
+		}
+		if len(shares) != int(test.participants) {
+			t.Fatalf("%d of %d: expected %d shares, got %d", test.threshold, test.participants, test.participants, len(shares))
+		}
+		if len(verificationVector) != int(test.threshold) {
+			t.Fatalf("%d of %d: expected verification vector of length %d, got %d", test.threshold, test.participants, test.threshold, len(verificationVector))
+		}
+
+		// Each share matches its public key from the verification vector.
+		for id, share := range shares {
+			var sec bls.SecretKey
+			if err := sec.Deserialize(share); err != nil {
+				t.Fatalf("%d of %d: invalid share %d: %v", test.threshold, test.participants, id, err)
+			}
+			pubKey, err := ThresholdSharePublicKey(verificationVector, id)
+			if err != nil {
+				t.Fatalf("%d of %d: failed to obtain public key for share %d: %v", test.threshold, test.participants, id, err)
+			}
+			if !bytes.Equal(sec.GetPublicKey().Serialize(), pubKey) {
+				t.Fatalf("%d of %d: share %d does not match verification vector", test.threshold, test.participants, id)
+			}
+		}
+
+		// Any threshold of shares recovers a signature that verifies against the composite public key.
+		for _, subset := range subsets(int(test.participants), int(test.threshold)) {
+			signature, err := RecoverThresholdSignature(testThresholdSign(t, shares, subset, msg))
+			if err != nil {
+				t.Fatalf("%d of %d: failed to recover signature from %v: %v", test.threshold, test.participants, subset, err)
+			}
+			if !testVerify(t, signature, verificationVector[0], msg) {
+				t.Fatalf("%d of %d: signature recovered from %v did not verify", test.threshold, test.participants, subset)
+			}
+		}
+
+		// Fewer than threshold shares do not.
+		if test.threshold > 1 {
+			for _, subset := range subsets(int(test.participants), int(test.threshold)-1) {
+				signature, err := RecoverThresholdSignature(testThresholdSign(t, shares, subset, msg))
+				if err != nil {
+					t.Fatalf("%d of %d: failed to recover signature from %v: %v", test.threshold, test.participants, subset, err)
+				}
+				if testVerify(t, signature, verificationVector[0], msg) {
+					t.Fatalf("%d of %d: signature recovered from %v unexpectedly verified", test.threshold, test.participants, subset)
+				}
+			}
+		}
+	}
+
+	if _, _, err := DistributedKeyGeneration(0, 3); err == nil {
+		t.Fatal("expected error with threshold 0")
+	}
+	if _, _, err := DistributedKeyGeneration(4, 3); err == nil {
+		t.Fatal("expected error with threshold above participants")
+	}
+}
+
+func TestThresholdShares(t *testing.T) {
+	if err := e2types.InitBLS(); err != nil {
+		t.Fatal(err)
+	}
+	var sec bls.SecretKey
+	sec.SetByCSPRNG()
+
+	shares, verificationVector, err := ThresholdShares(sec.Serialize(), 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(verificationVector[0], sec.GetPublicKey().Serialize()) {
+		t.Fatal("verification vector does not start with the public key")
+	}
+	for id, share := range shares {
+		var shareSec bls.SecretKey
+		if err := shareSec.Deserialize(share); err != nil {
+			t.Fatalf("invalid share %d: %v", id, err)
+		}
+		pubKey, err := ThresholdSharePublicKey(verificationVector, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(shareSec.GetPublicKey().Serialize(), pubKey) {
+			t.Fatalf("share %d does not match verification vector", id)
+		}
+	}
+
+	// Exactly threshold shares recover the key; fewer do not.
+	for _, size := range []int{3, 2} {
+		for _, subset := range subsets(5, size) {
+			selected := make(map[uint64][]byte, len(subset))
+			for _, id := range subset {
+				selected[uint64(id)] = shares[uint64(id)]
+			}
+			key, err := RecoverThresholdKey(selected)
+			if err != nil {
+				t.Fatalf("failed to recover key from %v: %v", subset, err)
+			}
+			if recovered := bytes.Equal(key, sec.Serialize()); recovered != (size == 3) {
+				t.Fatalf("key recovered from %v: %t", subset, recovered)
+			}
+		}
+	}
+
+	if _, _, err := ThresholdShares(sec.Serialize(), 4, 3); err == nil {
+		t.Fatal("expected error with threshold above participants")
+	}
+	if _, err := RecoverThresholdKey(nil); err == nil {
+		t.Fatal("expected error recovering from no shares")
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+// ClientKeystore is a validator key found in the on-disk layout of a client.
+type ClientKeystore struct {
+	// Location is the file from which the key was read.
+	Location string
+	// PublicKey is the public key stated by the keystore, if any.
+	PublicKey []byte
+	// Keystore is the EIP-2335 keystore holding the key.  It is nil for keys that the layout holds already
+	// decrypted with a wallet passphrase.
+	Keystore *Keystore
+	// Passphrase is the passphrase for the keystore held by the layout, if any.
+	Passphrase string
+	// PrivateKey is the private key, if it was decrypted when reading the layout.
+	PrivateKey []byte
+	// Err is set if the file could not be read as a keystore.
+	Err error
+}
+
+// Decrypt decrypts the private key, using the passphrase held by the layout if present or else the fallback passphrase.
+func (k *ClientKeystore) Decrypt(fallbackPassphrase string) ([]byte, error) {
+	if k.Err != nil {
+		return nil, k.Err
+	}
+	if k.PrivateKey != nil {
+		return k.PrivateKey, nil
+	}
+	passphrase := k.Passphrase
+	if passphrase == "" {
+		passphrase = fallbackPassphrase
+	}
+	if passphrase == "" {
+		return nil, errors.New("no passphrase for keystore")
+	}
+	return k.Keystore.Decrypt(keystorev4.New(), passphrase)
+}
+
+// ClientLayouts are the client layouts from which keys can be read.
+var ClientLayouts = []string{"deposit-cli", "lighthouse", "prysm", "teku"}
+
+// ReadClientKeystores finds the validator keys in the on-disk layout of a client.
+// passwordsPath is the directory holding password files, for clients that keep them apart from their keystores.
+// walletPassphrase decrypts layouts that hold keys together under a single passphrase.
+func ReadClientKeystores(client string, path string, passwordsPath string, walletPassphrase string) ([]*ClientKeystore, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.Wrap(err, "failed to access path")
+	}
+	switch client {
+	case "deposit-cli":
+		return readDepositCLIKeystores(path)
+	case "lighthouse":
+		return readLighthouseKeystores(path, passwordsPath)
+	case "prysm":
+		return readPrysmKeystores(path, walletPassphrase)
+	case "teku":
+		return readTekuKeystores(path, passwordsPath)
+	default:
+		return nil, fmt.Errorf("unknown client %q; supported clients are %s", client, strings.Join(ClientLayouts, ", "))
+	}
+}
+
+// readDepositCLIKeystores reads the keystores created by the deposit CLI in its validator_keys directory.
+// The deposit CLI does not store passphrases.
+func readDepositCLIKeystores(path string) ([]*ClientKeystore, error) {
+	if info, err := os.Stat(filepath.Join(path, "validator_keys")); err == nil && info.IsDir() {
+		path = filepath.Join(path, "validator_keys")
+	}
+	filenames, err := filepath.Glob(filepath.Join(path, "keystore-*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list keystores")
+	}
+	sort.Strings(filenames)
+	keystores := make([]*ClientKeystore, 0, len(filenames))
+	for _, filename := range filenames {
+		keystore, err := readClientKeystore(filename)
+		if err != nil {
+			keystores = append(keystores, &ClientKeystore{Location: filename, Err: err})
+			continue
+		}
+		keystores = append(keystores, keystore)
+	}
+	return keystores, nil
+}
+
+// readLighthouseKeystores reads the keystores from a Lighthouse validators directory, in which each validator has
+// a directory named for its public key holding voting-keystore.json.  Passphrases are held in a secrets directory
+// alongside the validators directory, in files named for the public key.
+func readLighthouseKeystores(path string, passwordsPath string) ([]*ClientKeystore, error) {
+	validatorsDir := path
+	if info, err := os.Stat(filepath.Join(path, "validators")); err == nil && info.IsDir() {
+		validatorsDir = filepath.Join(path, "validators")
+	}
+	secretsDir := passwordsPath
+	if secretsDir == "" {
+		secretsDir = filepath.Join(filepath.Dir(validatorsDir), "secrets")
+	}
+	filenames, err := filepath.Glob(filepath.Join(validatorsDir, "*", "voting-keystore.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list keystores")
+	}
+	sort.Strings(filenames)
+	keystores := make([]*ClientKeystore, 0, len(filenames))
+	for _, filename := range filenames {
+		keystore, err := readClientKeystore(filename)
+		if err != nil {
+			keystores = append(keystores, &ClientKeystore{Location: filename, Err: err})
+			continue
+		}
+		keystore.Passphrase = readPasswordFile(filepath.Join(secretsDir, filepath.Base(filepath.Dir(filename))))
+		keystores = append(keystores, keystore)
+	}
+	return keystores, nil
+}
+
+// readTekuKeystores reads the keystores from a Teku keys directory.  Each keystore has a passphrase in a file of the
+// same name with the extension .txt, held in the passwords directory or else alongside the keystore.
+func readTekuKeystores(path string, passwordsPath string) ([]*ClientKeystore, error) {
+	if passwordsPath == "" {
+		passwordsPath = path
+	}
+	filenames, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list keystores")
+	}
+	sort.Strings(filenames)
+	keystores := make([]*ClientKeystore, 0, len(filenames))
+	for _, filename := range filenames {
+		keystore, err := readClientKeystore(filename)
+		if err != nil {
+			keystores = append(keystores, &ClientKeystore{Location: filename, Err: err})
+			continue
+		}
+		keystore.Passphrase = readPasswordFile(filepath.Join(passwordsPath, strings.TrimSuffix(filepath.Base(filename), ".json")+".txt"))
+		keystores = append(keystores, keystore)
+	}
+	return keystores, nil
+}
+
+// readPrysmKeystores reads the keys from a Prysm wallet with imported accounts.  Prysm holds all of its keys in a
+// single keystore, encrypted with the wallet passphrase.
+func readPrysmKeystores(path string, walletPassphrase string) ([]*ClientKeystore, error) {
+	filename := filepath.Join(path, "direct", "accounts", "all-accounts.keystore.json")
+	if _, err := os.Stat(filename); err != nil {
+		if _, derivedErr := os.Stat(filepath.Join(path, "derived")); derivedErr == nil {
+			return nil, errors.New("Prysm wallets with derived accounts are not supported; import the wallet's mnemonic instead")
+		}
+		return nil, errors.New("no Prysm accounts keystore found")
+	}
+	if walletPassphrase == "" {
+		return nil, errors.New("the Prysm wallet passphrase is required")
+	}
+	keystore, err := readClientKeystore(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read Prysm accounts keystore")
+	}
+	data, err := keystore.Keystore.Decrypt(keystorev4.New(), walletPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt Prysm accounts keystore")
+	}
+	accounts := &struct {
+		PrivateKeys [][]byte `json:"private_keys"`
+		PublicKeys  [][]byte `json:"public_keys"`
+	}{}
+	if err := json.Unmarshal(data, accounts); err != nil {
+		return nil, errors.Wrap(err, "failed to parse Prysm accounts keystore")
+	}
+	if len(accounts.PrivateKeys) != len(accounts.PublicKeys) {
+		return nil, errors.New("Prysm accounts keystore has mismatched keys")
+	}
+	keystores := make([]*ClientKeystore, len(accounts.PrivateKeys))
+	for i := range accounts.PrivateKeys {
+		keystores[i] = &ClientKeystore{
+			Location:   fmt.Sprintf("%s#%d", filename, i),
+			PublicKey:  accounts.PublicKeys[i],
+			PrivateKey: accounts.PrivateKeys[i],
+		}
+	}
+	return keystores, nil
+}
+
+// readClientKeystore reads an EIP-2335 keystore.
+func readClientKeystore(filename string) (*ClientKeystore, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file")
+	}
+	// Parse only the fields required, as clients differ in the other fields they provide.
+	input := &struct {
+		Crypto    map[string]interface{} `json:"crypto"`
+		PublicKey string                 `json:"pubkey"`
+		Path      string                 `json:"path"`
+		Version   uint                   `json:"version"`
+	}{}
+	if err := json.Unmarshal(data, input); err != nil {
+		return nil, errors.Wrap(err, "failed to parse file")
+	}
+	keystore := &Keystore{
+		Crypto:    input.Crypto,
+		PublicKey: input.PublicKey,
+		Path:      input.Path,
+		Version:   input.Version,
+	}
+	if keystore.Crypto == nil {
+		return nil, errors.New("not a keystore")
+	}
+	if keystore.Version != 4 {
+		return nil, fmt.Errorf("unsupported keystore version %d", keystore.Version)
+	}
+	res := &ClientKeystore{
+		Location: filename,
+		Keystore: keystore,
+	}
+	if keystore.PublicKey != "" {
+		res.PublicKey, err = hex.DecodeString(strings.TrimPrefix(keystore.PublicKey, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid public key")
+		}
+	}
+	return res, nil
+}
+
+// readPasswordFile reads a passphrase from a file, returning an empty passphrase if the file does not exist.
+func readPasswordFile(filename string) string {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+// ClientExportKey is a validator key to write in to the on-disk layout of a client.
+type ClientExportKey struct {
+	PrivateKey []byte
+	PublicKey  []byte
+	// Path is the derivation path of the key, if known.
+	Path string
+	// Passphrase is the passphrase with which to encrypt the key's keystore.
+	Passphrase string
+}
+
+// ClientExportLayouts are the client layouts to which keys can be written.
+var ClientExportLayouts = []string{"lighthouse", "nimbus", "prysm", "teku"}
+
+// WriteClientKeystores writes validator keys in to the on-disk layout of a client at the given path, which must not
+// already contain files.  walletPassphrase encrypts layouts that hold keys together under a single passphrase.
+// It returns the files written.  If any file cannot be written, the files and directories already created are removed.
+func WriteClientKeystores(client string, path string, keys []*ClientExportKey, walletPassphrase string) ([]string, error) {
+	switch client {
+	case "lighthouse", "nimbus", "prysm", "teku":
+	default:
+		return nil, fmt.Errorf("unknown client %q; supported clients are %s", client, strings.Join(ClientExportLayouts, ", "))
+	}
+	if entries, err := ioutil.ReadDir(path); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("%s is not empty", path)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain absolute path")
+	}
+
+	writer := &clientLayoutWriter{
+		files: make([]string, 0),
+		dirs:  make([]string, 0),
+	}
+	switch client {
+	case "lighthouse":
+		err = writer.writeLighthouse(absPath, keys)
+	case "nimbus":
+		err = writer.writeNimbus(absPath, keys)
+	case "prysm":
+		err = writer.writePrysm(absPath, keys, walletPassphrase)
+	case "teku":
+		err = writer.writeTeku(absPath, keys)
+	}
+	if err != nil {
+		writer.remove()
+		return nil, err
+	}
+	return writer.files, nil
+}
+
+// clientLayoutWriter writes files, keeping track of those written and the directories created for them.
+type clientLayoutWriter struct {
+	files []string
+	dirs  []string
+}
+
+func (w *clientLayoutWriter) write(filename string, data []byte) error {
+	for dir := filepath.Dir(filename); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+			break
+		}
+		w.dirs = append(w.dirs, dir)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+	if err := ioutil.WriteFile(filename, data, 0600); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to write %s", filename))
+	}
+	w.files = append(w.files, filename)
+	return nil
+}
+
+// remove removes the files written and the directories created, deepest first.
+func (w *clientLayoutWriter) remove() {
+	for _, filename := range w.files {
+		// Nothing useful can be done if removal fails, as the write has already failed.
+		_ = os.Remove(filename)
+	}
+	sort.Slice(w.dirs, func(i, j int) bool {
+		return len(w.dirs[i]) > len(w.dirs[j])
+	})
+	for _, dir := range w.dirs {
+		_ = os.Remove(dir)
+	}
+}
+
+func (w *clientLayoutWriter) writeKeystore(filename string, key *ClientExportKey) error {
+	keystore, err := NewKeystore(keystorev4.New(), key.PrivateKey, key.PublicKey, key.Path, key.Passphrase)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(keystore)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate keystore")
+	}
+	return w.write(filename, data)
+}
+
+// writeLighthouse writes keys as per Lighthouse's validators and secrets directories, along with a
+// validator_definitions.yml that refers to them.
+func (w *clientLayoutWriter) writeLighthouse(path string, keys []*ClientExportKey) error {
+	var definitions strings.Builder
+	definitions.WriteString("---\n")
+	for _, key := range keys {
+		name := fmt.Sprintf("%#x", key.PublicKey)
+		keystoreFilename := filepath.Join(path, "validators", name, "voting-keystore.json")
+		passwordFilename := filepath.Join(path, "secrets", name)
+		if err := w.writeKeystore(keystoreFilename, key); err != nil {
+			return err
+		}
+		if err := w.write(passwordFilename, []byte(key.Passphrase)); err != nil {
+			return err
+		}
+		definitions.WriteString("- enabled: true\n")
+		definitions.WriteString(fmt.Sprintf("  voting_public_key: \"%s\"\n", name))
+		definitions.WriteString("  type: local_keystore\n")
+		definitions.WriteString(fmt.Sprintf("  voting_keystore_path: \"%s\"\n", keystoreFilename))
+		definitions.WriteString(fmt.Sprintf("  voting_keystore_password_path: \"%s\"\n", passwordFilename))
+	}
+	return w.write(filepath.Join(path, "validators", "validator_definitions.yml"), []byte(definitions.String()))
+}
+
+// writeNimbus writes keys as per Nimbus's validators and secrets directories.
+func (w *clientLayoutWriter) writeNimbus(path string, keys []*ClientExportKey) error {
+	for _, key := range keys {
+		name := fmt.Sprintf("%#x", key.PublicKey)
+		if err := w.writeKeystore(filepath.Join(path, "validators", name, "keystore.json"), key); err != nil {
+			return err
+		}
+		if err := w.write(filepath.Join(path, "secrets", name), []byte(key.Passphrase)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTeku writes keys as per Teku's keys and passwords directories, with each password file named for its keystore.
+func (w *clientLayoutWriter) writeTeku(path string, keys []*ClientExportKey) error {
+	for _, key := range keys {
+		name := fmt.Sprintf("%#x", key.PublicKey)
+		if err := w.writeKeystore(filepath.Join(path, "keys", name+".json"), key); err != nil {
+			return err
+		}
+		if err := w.write(filepath.Join(path, "passwords", name+".txt"), []byte(key.Passphrase)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePrysm writes keys as per a Prysm wallet with imported accounts, in which all keys are held in a single
+// keystore encrypted with the wallet passphrase.
+func (w *clientLayoutWriter) writePrysm(path string, keys []*ClientExportKey, walletPassphrase string) error {
+	if walletPassphrase == "" {
+		return errors.New("a wallet passphrase is required for Prysm")
+	}
+	accounts := &struct {
+		PrivateKeys [][]byte `json:"private_keys"`
+		PublicKeys  [][]byte `json:"public_keys"`
+	}{
+		PrivateKeys: make([][]byte, len(keys)),
+		PublicKeys:  make([][]byte, len(keys)),
+	}
+	for i, key := range keys {
+		accounts.PrivateKeys[i] = key.PrivateKey
+		accounts.PublicKeys[i] = key.PublicKey
+	}
+	data, err := json.Marshal(accounts)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate accounts")
+	}
+	keystore, err := NewKeystore(keystorev4.New(), data, nil, "", walletPassphrase)
+	if err != nil {
+		return err
+	}
+	keystore.PublicKey = ""
+	data, err = json.Marshal(keystore)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate keystore")
+	}
+	return w.write(filepath.Join(path, "direct", "accounts", "all-accounts.keystore.json"), data)
+}
@@ -0,0 +1,106 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func newTestExportKey(t *testing.T) *ClientExportKey {
+	if err := e2types.InitBLS(); err != nil {
+		t.Fatalf("failed to initialise BLS: %v", err)
+	}
+	privateKey, err := e2types.GenerateBLSPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return &ClientExportKey{
+		PrivateKey: privateKey.Marshal(),
+		PublicKey:  privateKey.PublicKey().Marshal(),
+		Passphrase: "secret",
+	}
+}
+
+func TestWriteClientKeystoresRoundTrip(t *testing.T) {
+	base, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(base)
+	path := filepath.Join(base, "lighthouse")
+
+	key := newTestExportKey(t)
+	files, err := WriteClientKeystores("lighthouse", path, []*ClientExportKey{key}, "")
+	if err != nil {
+		t.Fatalf("failed to write keys: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+
+	keystores, err := ReadClientKeystores("lighthouse", filepath.Join(path, "validators"), "", "")
+	if err != nil {
+		t.Fatalf("failed to read keys: %v", err)
+	}
+	if len(keystores) != 1 {
+		t.Fatalf("expected 1 keystore, got %d", len(keystores))
+	}
+	privateKey, err := keystores[0].Decrypt("")
+	if err != nil {
+		t.Fatalf("failed to decrypt key: %v", err)
+	}
+	if !bytes.Equal(privateKey, key.PrivateKey) {
+		t.Fatal("private key does not match")
+	}
+
+	if _, err := WriteClientKeystores("lighthouse", path, []*ClientExportKey{key}, ""); err == nil {
+		t.Fatal("wrote keys to non-empty directory")
+	}
+}
+
+func TestWriteClientKeystoresCleanup(t *testing.T) {
+	base, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(base)
+
+	for _, client := range []string{"lighthouse", "nimbus", "teku"} {
+		t.Run(client, func(t *testing.T) {
+			path := filepath.Join(base, client, "keys")
+			// The second key cannot be encrypted, so the write fails after the first key is written.
+			badKey := newTestExportKey(t)
+			badKey.PrivateKey = nil
+			files, err := WriteClientKeystores(client, path, []*ClientExportKey{newTestExportKey(t), badKey}, "")
+			if err == nil {
+				t.Fatal("expected write to fail")
+			}
+			if files != nil {
+				t.Fatalf("files returned for failed write: %v", files)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("%s remains after failed write", path)
+			}
+			if _, err := os.Stat(filepath.Join(base, client)); !os.IsNotExist(err) {
+				t.Fatalf("parent directory remains after failed write")
+			}
+		})
+	}
+}
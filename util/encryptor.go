@@ -0,0 +1,254 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// KDFParams are the parameters of the key derivation function used to encrypt a keystore.
+type KDFParams struct {
+	Function string `json:"function"`
+	// C is the number of iterations for PBKDF2.
+	C int `json:"c,omitempty"`
+	// N, R and P are the CPU/memory cost, block size and parallelisation for scrypt.
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+}
+
+// DefaultKDFParams returns the parameters used by the standard keystore encryptor.
+func DefaultKDFParams(function string) (*KDFParams, error) {
+	switch function {
+	case "pbkdf2":
+		return &KDFParams{Function: "pbkdf2", C: 262144}, nil
+	case "scrypt":
+		return &KDFParams{Function: "scrypt", N: 262144, R: 8, P: 1}, nil
+	default:
+		return nil, fmt.Errorf("unknown key derivation function %q", function)
+	}
+}
+
+// Validate checks that the parameters are usable.
+func (p *KDFParams) Validate() error {
+	switch p.Function {
+	case "pbkdf2":
+		if p.C < 1 {
+			return errors.New("pbkdf2 iterations must be at least 1")
+		}
+	case "scrypt":
+		if p.N <= 1 || p.N&(p.N-1) != 0 {
+			return errors.New("scrypt n must be a power of 2 greater than 1")
+		}
+		if p.R < 1 || p.P < 1 {
+			return errors.New("scrypt r and p must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unknown key derivation function %q", p.Function)
+	}
+	return nil
+}
+
+// Equal returns true if the parameters are the same.
+func (p *KDFParams) Equal(other *KDFParams) bool {
+	return *p == *other
+}
+
+// String provides a human-readable description of the parameters.
+func (p *KDFParams) String() string {
+	switch p.Function {
+	case "pbkdf2":
+		return fmt.Sprintf("pbkdf2 (c=%d)", p.C)
+	case "scrypt":
+		return fmt.Sprintf("scrypt (n=%d, r=%d, p=%d)", p.N, p.R, p.P)
+	default:
+		return p.Function
+	}
+}
+
+// KDFParamsFromCrypto obtains the key derivation function parameters from the crypto section of a keystore.
+func KDFParamsFromCrypto(crypto map[string]interface{}) (*KDFParams, error) {
+	data, err := json.Marshal(crypto["kdf"])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse key derivation function")
+	}
+	kdf := &struct {
+		Function string     `json:"function"`
+		Params   *KDFParams `json:"params"`
+	}{}
+	if err := json.Unmarshal(data, kdf); err != nil {
+		return nil, errors.Wrap(err, "failed to parse key derivation function")
+	}
+	if kdf.Params == nil {
+		return nil, errors.New("keystore has no key derivation function")
+	}
+	kdf.Params.Function = kdf.Function
+	return kdf.Params, kdf.Params.Validate()
+}
+
+// KeystoreEncryptor is an EIP-2335 keystore encryptor with configurable key derivation parameters.
+// Its output can be decrypted by the standard keystore encryptor.
+type KeystoreEncryptor struct {
+	params    *KDFParams
+	decryptor *keystorev4.Encryptor
+}
+
+// NewKeystoreEncryptor creates a keystore encryptor with the given key derivation parameters.
+func NewKeystoreEncryptor(params *KDFParams) (*KeystoreEncryptor, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &KeystoreEncryptor{
+		params:    params,
+		decryptor: keystorev4.New(),
+	}, nil
+}
+
+// Name returns the name of this encryptor.
+func (e *KeystoreEncryptor) Name() string {
+	return e.decryptor.Name()
+}
+
+// Version returns the version of this encryptor.
+func (e *KeystoreEncryptor) Version() uint {
+	return e.decryptor.Version()
+}
+
+// Params returns the key derivation parameters of this encryptor.
+func (e *KeystoreEncryptor) Params() *KDFParams {
+	return e.params
+}
+
+// Decrypt decrypts data.
+func (e *KeystoreEncryptor) Decrypt(data map[string]interface{}, passphrase string) ([]byte, error) {
+	return e.decryptor.Decrypt(data, passphrase)
+}
+
+// Encrypt encrypts data.
+func (e *KeystoreEncryptor) Encrypt(secret []byte, passphrase string) (map[string]interface{}, error) {
+	if secret == nil {
+		return nil, errors.New("no secret")
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	normedPassphrase := []byte(normPassphrase(passphrase))
+
+	kdfParams := map[string]interface{}{
+		"dklen": 32,
+		"salt":  hex.EncodeToString(salt),
+	}
+	var decryptionKey []byte
+	var err error
+	switch e.params.Function {
+	case "pbkdf2":
+		decryptionKey = pbkdf2.Key(normedPassphrase, salt, e.params.C, 32, sha256.New)
+		kdfParams["c"] = e.params.C
+		kdfParams["prf"] = "hmac-sha256"
+	case "scrypt":
+		decryptionKey, err = scrypt.Key(normedPassphrase, salt, e.params.N, e.params.R, e.params.P, 32)
+		if err != nil {
+			return nil, err
+		}
+		kdfParams["n"] = e.params.N
+		kdfParams["r"] = e.params.R
+		kdfParams["p"] = e.params.P
+	}
+
+	aesCipher, err := aes.NewCipher(decryptionKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, 16)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	cipherMsg := make([]byte, len(secret))
+	cipher.NewCTR(aesCipher, iv).XORKeyStream(cipherMsg, secret)
+
+	h := sha256.New()
+	if _, err := h.Write(decryptionKey[16:32]); err != nil {
+		return nil, err
+	}
+	if _, err := h.Write(cipherMsg); err != nil {
+		return nil, err
+	}
+
+	output := map[string]interface{}{
+		"kdf": map[string]interface{}{
+			"function": e.params.Function,
+			"params":   kdfParams,
+			"message":  "",
+		},
+		"checksum": map[string]interface{}{
+			"function": "sha256",
+			"params":   map[string]interface{}{},
+			"message":  hex.EncodeToString(h.Sum(nil)),
+		},
+		"cipher": map[string]interface{}{
+			"function": "aes-128-ctr",
+			"params": map[string]interface{}{
+				"iv": hex.EncodeToString(iv),
+			},
+			"message": hex.EncodeToString(cipherMsg),
+		},
+	}
+
+	// Return the generic map that would be obtained from JSON, as per the standard keystore encryptor.
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]interface{})
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// normPassphrase normalises a passphrase in the same way as the standard keystore encryptor,
+// so that keystores created by either encryptor can be decrypted by the other.
+func normPassphrase(input string) string {
+	var output []byte
+
+	iter := &norm.Iter{}
+	iter.InitString(norm.NFKD, input)
+	for !iter.Done() {
+		r, _ := utf8.DecodeRune(iter.Next())
+		buf := make([]byte, utf8.RuneLen(r))
+		utf8.EncodeRune(buf, r)
+		if len(buf) == 1 && (buf[0] < 0x20 || buf[0] == 0x7f) {
+			// Control character.
+			continue
+		}
+		output = norm.NFKD.Append(output, buf...)
+	}
+
+	return string(output)
+}
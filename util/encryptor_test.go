@@ -0,0 +1,143 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+func TestKeystoreEncryptorRoundTrip(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x25}, 32)
+	params := []*KDFParams{
+		{Function: "pbkdf2", C: 16},
+		{Function: "scrypt", N: 16, R: 8, P: 1},
+	}
+	passphrases := []string{
+		"",
+		"secret",
+		// Control characters are removed and Unicode is normalised to NFKD, as per EIP-2335.
+		"\u00e9\u0007 \u212b\u007f\tpass\u00adword\U0001f511",
+	}
+
+	// Normalisation matches the standard keystore encryptor, which keeps only the first rune of each NFKD segment.
+	if normPassphrase(passphrases[2]) != "e Apass\u00adword\U0001f511" {
+		t.Fatalf("unexpected normalised passphrase %q", normPassphrase(passphrases[2]))
+	}
+
+	for _, param := range params {
+		encryptor, err := NewKeystoreEncryptor(param)
+		if err != nil {
+			t.Fatalf("%s: failed to create encryptor: %v", param, err)
+		}
+		for _, passphrase := range passphrases {
+			crypto, err := encryptor.Encrypt(secret, passphrase)
+			if err != nil {
+				t.Fatalf("%s: failed to encrypt with passphrase %q: %v", param, passphrase, err)
+			}
+
+			// The standard keystore encryptor decrypts the output.
+			decrypted, err := keystorev4.New().Decrypt(crypto, passphrase)
+			if err != nil {
+				t.Fatalf("%s: failed to decrypt with passphrase %q: %v", param, passphrase, err)
+			}
+			if !bytes.Equal(decrypted, secret) {
+				t.Fatalf("%s: incorrect secret decrypted with passphrase %q", param, passphrase)
+			}
+			if _, err := keystorev4.New().Decrypt(crypto, passphrase+"x"); err == nil {
+				t.Fatalf("%s: decrypted with incorrect passphrase", param)
+			}
+
+			// Equivalent passphrases decrypt the output.
+			if _, err := keystorev4.New().Decrypt(crypto, normPassphrase(passphrase)); err != nil {
+				t.Fatalf("%s: failed to decrypt with normalised passphrase %q: %v", param, passphrase, err)
+			}
+
+			// The parameters are read back as written.
+			readParams, err := KDFParamsFromCrypto(crypto)
+			if err != nil {
+				t.Fatalf("%s: failed to obtain parameters: %v", param, err)
+			}
+			if !readParams.Equal(param) {
+				t.Fatalf("%s: parameters read back as %s", param, readParams)
+			}
+		}
+	}
+}
+
+func TestKeystoreEncryptorDecryptsStandard(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x25}, 32)
+	passphrase := "\u00e9\u0007 \u212b\u007fpass"
+	crypto, err := keystorev4.New().Encrypt(secret, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptor, err := NewKeystoreEncryptor(&KDFParams{Function: "pbkdf2", C: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := encryptor.Decrypt(crypto, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, secret) {
+		t.Fatal("incorrect secret decrypted")
+	}
+
+	params, err := KDFParamsFromCrypto(crypto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultParams, err := DefaultKDFParams("pbkdf2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !params.Equal(defaultParams) {
+		t.Fatalf("standard parameters read back as %s", params)
+	}
+}
+
+func TestKDFParamsFromCryptoInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		crypto map[string]interface{}
+	}{
+		{
+			name:   "Missing",
+			crypto: map[string]interface{}{},
+		},
+		{
+			name: "UnknownFunction",
+			crypto: map[string]interface{}{
+				"kdf": map[string]interface{}{"function": "argon2", "params": map[string]interface{}{}},
+			},
+		},
+		{
+			name: "InvalidScrypt",
+			crypto: map[string]interface{}{
+				"kdf": map[string]interface{}{"function": "scrypt", "params": map[string]interface{}{"n": 3, "r": 8, "p": 1}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := KDFParamsFromCrypto(test.crypto); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
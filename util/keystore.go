@@ -0,0 +1,55 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// Keystore is an EIP-2335 keystore.
+type Keystore struct {
+	Crypto      map[string]interface{} `json:"crypto"`
+	Description string                 `json:"description,omitempty"`
+	PublicKey   string                 `json:"pubkey"`
+	Path        string                 `json:"path"`
+	UUID        uuid.UUID              `json:"uuid"`
+	Version     uint                   `json:"version"`
+}
+
+// NewKeystore creates a keystore for a private key, encrypted with the given passphrase.
+func NewKeystore(encryptor e2wtypes.Encryptor, privateKey []byte, publicKey []byte, path string, passphrase string) (*Keystore, error) {
+	crypto, err := encryptor.Encrypt(privateKey, passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt private key")
+	}
+	return &Keystore{
+		Crypto:    crypto,
+		PublicKey: fmt.Sprintf("%x", publicKey),
+		Path:      path,
+		UUID:      uuid.New(),
+		Version:   encryptor.Version(),
+	}, nil
+}
+
+// Decrypt decrypts the private key in the keystore with the given passphrase.
+func (k *Keystore) Decrypt(encryptor e2wtypes.Encryptor, passphrase string) ([]byte, error) {
+	if k.Crypto == nil {
+		return nil, errors.New("keystore has no crypto section")
+	}
+	return encryptor.Decrypt(k.Crypto, passphrase)
+}
@@ -0,0 +1,145 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// MemoryStore is a wallet store held in memory, for working on copies of wallets that must not touch a real store.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	wallets  map[uuid.UUID][]byte
+	accounts map[uuid.UUID]map[uuid.UUID][]byte
+	indices  map[uuid.UUID][]byte
+}
+
+// NewMemoryStore creates a new, empty, in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		wallets:  make(map[uuid.UUID][]byte),
+		accounts: make(map[uuid.UUID]map[uuid.UUID][]byte),
+		indices:  make(map[uuid.UUID][]byte),
+	}
+}
+
+// Name returns the name of this store.
+func (s *MemoryStore) Name() string {
+	return "memory"
+}
+
+// StoreWallet stores wallet-level data.
+func (s *MemoryStore) StoreWallet(walletID uuid.UUID, walletName string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wallets[walletID] = append([]byte{}, data...)
+	if _, exists := s.accounts[walletID]; !exists {
+		s.accounts[walletID] = make(map[uuid.UUID][]byte)
+	}
+	return nil
+}
+
+// RetrieveWallet retrieves wallet-level data for a wallet with a given name.
+func (s *MemoryStore) RetrieveWallet(walletName string) ([]byte, error) {
+	for data := range s.RetrieveWallets() {
+		info := &struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(data, info); err == nil && info.Name == walletName {
+			return data, nil
+		}
+	}
+	return nil, errors.New("wallet not found")
+}
+
+// RetrieveWalletByID retrieves wallet-level data for a wallet with a given ID.
+func (s *MemoryStore) RetrieveWalletByID(walletID uuid.UUID) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, exists := s.wallets[walletID]
+	if !exists {
+		return nil, errors.New("wallet not found")
+	}
+	return data, nil
+}
+
+// RetrieveWallets retrieves wallet-level data for all wallets.
+func (s *MemoryStore) RetrieveWallets() <-chan []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ch := make(chan []byte, len(s.wallets))
+	for _, data := range s.wallets {
+		ch <- data
+	}
+	close(ch)
+	return ch
+}
+
+// StoreAccount stores an account.
+func (s *MemoryStore) StoreAccount(walletID uuid.UUID, accountID uuid.UUID, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts, exists := s.accounts[walletID]
+	if !exists {
+		return errors.New("unknown wallet")
+	}
+	accounts[accountID] = append([]byte{}, data...)
+	return nil
+}
+
+// RetrieveAccount retrieves account-level data for a given account.
+func (s *MemoryStore) RetrieveAccount(walletID uuid.UUID, accountID uuid.UUID) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, exists := s.accounts[walletID][accountID]
+	if !exists {
+		return nil, errors.New("account not found")
+	}
+	return data, nil
+}
+
+// RetrieveAccounts retrieves all account-level data for a wallet.
+func (s *MemoryStore) RetrieveAccounts(walletID uuid.UUID) <-chan []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ch := make(chan []byte, len(s.accounts[walletID]))
+	for _, data := range s.accounts[walletID] {
+		ch <- data
+	}
+	close(ch)
+	return ch
+}
+
+// StoreAccountsIndex stores the index of accounts for a given wallet.
+func (s *MemoryStore) StoreAccountsIndex(walletID uuid.UUID, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indices[walletID] = append([]byte{}, data...)
+	return nil
+}
+
+// RetrieveAccountsIndex retrieves the index of accounts for a given wallet.
+func (s *MemoryStore) RetrieveAccountsIndex(walletID uuid.UUID) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, exists := s.indices[walletID]
+	if !exists {
+		return nil, errors.New("index not found")
+	}
+	return data, nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	bip39 "github.com/tyler-smith/go-bip39"
+	e2util "github.com/wealdtech/go-eth2-util"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MnemonicWordCounts are the valid numbers of words in a mnemonic.
+var MnemonicWordCounts = []int{24, 21, 18, 15, 12}
+
+// NormaliseMnemonic normalises a mnemonic, converting it to NFKD form and lower case, and separating words with
+// single spaces.
+func NormaliseMnemonic(mnemonic string) string {
+	mnemonic = string(norm.NFKD.Bytes([]byte(mnemonic)))
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
+
+// SplitMnemonic splits input in to a mnemonic and its passphrase.  Anything after the valid mnemonic, less the
+// single separator that follows its last word, is treated as the passphrase.  The mnemonic is normalised; the
+// passphrase is kept as typed other than being converted to NFKD form.
+func SplitMnemonic(input string) (string, string, error) {
+	input = string(norm.NFKD.Bytes([]byte(input)))
+	words := strings.Fields(input)
+	for _, count := range MnemonicWordCounts {
+		if len(words) < count {
+			continue
+		}
+		mnemonic := NormaliseMnemonic(strings.Join(words[:count], " "))
+		// Obtaining the entropy confirms the checksum as well as the words.
+		if _, err := bip39.EntropyFromMnemonic(mnemonic); err == nil {
+			return mnemonic, mnemonicRemainder(input, count), nil
+		}
+	}
+	return "", "", errors.New("mnemonic is not valid")
+}
+
+// mnemonicRemainder returns the input following the given number of words and the single separator after them.
+func mnemonicRemainder(input string, count int) string {
+	inWord := false
+	for i, r := range input {
+		if unicode.IsSpace(r) {
+			if inWord {
+				count--
+				if count == 0 {
+					return input[i+utf8.RuneLen(r):]
+				}
+			}
+			inWord = false
+		} else {
+			inWord = true
+		}
+	}
+	return ""
+}
+
+// SeedFromMnemonic creates a seed from a mnemonic.  Any words after the mnemonic are treated as the passphrase.
+func SeedFromMnemonic(input string) ([]byte, error) {
+	mnemonic, passphrase, err := SplitMnemonic(input)
+	if err != nil {
+		return nil, err
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// SeedFingerprint provides a fingerprint for a seed, being the first 4 bytes of the SHA-256 hash of its
+// EIP-2333 master public key.  The fingerprint identifies a seed without revealing any keys.
+func SeedFingerprint(seed []byte) ([]byte, error) {
+	masterKey, err := e2util.PrivateKeyFromSeedAndPath(seed, "m")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate master key")
+	}
+	return e2util.SHA256(masterKey.PublicKey().Marshal())[:4], nil
+}
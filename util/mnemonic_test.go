@@ -0,0 +1,106 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+func TestSplitMnemonic(t *testing.T) {
+	mnemonic12 := strings.Repeat("abandon ", 11) + "about"
+	mnemonic24 := strings.Repeat("abandon ", 23) + "art"
+
+	tests := []struct {
+		name       string
+		input      string
+		mnemonic   string
+		passphrase string
+		err        string
+	}{
+		{
+			name:     "Mnemonic12",
+			input:    mnemonic12,
+			mnemonic: mnemonic12,
+		},
+		{
+			name:     "Mnemonic24",
+			input:    mnemonic24,
+			mnemonic: mnemonic24,
+		},
+		{
+			name:     "Normalised",
+			input:    "  " + strings.Replace(strings.ToUpper(mnemonic12), " ", "\t ", -1),
+			mnemonic: mnemonic12,
+		},
+		{
+			name:       "Passphrase",
+			input:      mnemonic24 + " secret",
+			mnemonic:   mnemonic24,
+			passphrase: "secret",
+		},
+		{
+			name:       "PassphraseAsTyped",
+			input:      mnemonic24 + "  Two  Spaces\tand a tab ",
+			mnemonic:   mnemonic24,
+			passphrase: " Two  Spaces\tand a tab ",
+		},
+		{
+			name:       "PassphraseNFKD",
+			input:      mnemonic12 + " caf\u00e9",
+			mnemonic:   mnemonic12,
+			passphrase: "cafe\u0301",
+		},
+		{
+			name:  "Invalid",
+			input: strings.Repeat("abandon ", 12),
+			err:   "mnemonic is not valid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mnemonic, passphrase, err := SplitMnemonic(test.input)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mnemonic != test.mnemonic {
+				t.Errorf("expected mnemonic %q, got %q", test.mnemonic, mnemonic)
+			}
+			if passphrase != test.passphrase {
+				t.Errorf("expected passphrase %q, got %q", test.passphrase, passphrase)
+			}
+		})
+	}
+}
+
+func TestSeedFromMnemonicPassphrase(t *testing.T) {
+	mnemonic := strings.Repeat("abandon ", 23) + "art"
+	seed, err := SeedFromMnemonic(mnemonic + " a  b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(seed, bip39.NewSeed(mnemonic, "a  b")) {
+		t.Fatal("seed does not use the passphrase as typed")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	bip39 "github.com/tyler-smith/go-bip39"
+	e2util "github.com/wealdtech/go-eth2-util"
+)
+
+// Mnemonic shares are an ethdo-specific format.  They resemble SLIP-39 shares but are not compatible with them: there
+// are no groups, the word list is BIP-39's rather than SLIP-39's, the checksum is SHA-256 rather than RS1024, and the
+// entropy is not encrypted with a passphrase.  Shares can only be recombined by ethdo.
+//
+// Shares are encoded with the BIP-39 word list.  Each share contains the following fields:
+//   - version (1 byte)
+//   - identifier, common to all shares of the same entropy (2 bytes)
+//   - threshold (1 byte)
+//   - index (1 byte)
+//   - share of the entropy (16 to 32 bytes)
+//   - checksum, being the first 4 bytes of the SHA-256 hash of the preceding fields (4 bytes)
+const (
+	mnemonicShareVersion      = 1
+	mnemonicShareHeaderLen    = 5
+	mnemonicShareChecksumLen  = 4
+	mnemonicShareBitsPerWord  = 11
+	mnemonicShareMinEntropy   = 16
+	mnemonicShareMaxEntropy   = 32
+	mnemonicShareEntropyDelta = 4
+)
+
+// mnemonicShare is a decoded mnemonic share.
+type mnemonicShare struct {
+	identifier []byte
+	threshold  byte
+	index      byte
+	data       []byte
+}
+
+// MnemonicSharesFromEntropy splits the entropy of a mnemonic in to the given number of shares, any threshold of
+// which can recreate the entropy.  Each share is returned as a list of words.
+func MnemonicSharesFromEntropy(entropy []byte, threshold int, shares int) ([]string, error) {
+	if len(entropy) < mnemonicShareMinEntropy || len(entropy) > mnemonicShareMaxEntropy || len(entropy)%mnemonicShareEntropyDelta != 0 {
+		return nil, errors.New("invalid entropy length")
+	}
+	if threshold < 2 {
+		return nil, errors.New("threshold must be at least 2")
+	}
+	secretShares, err := ShamirSplit(entropy, threshold, shares)
+	if err != nil {
+		return nil, err
+	}
+	identifier := make([]byte, 2)
+	if _, err := rand.Read(identifier); err != nil {
+		return nil, errors.Wrap(err, "failed to generate identifier")
+	}
+
+	res := make([]string, shares)
+	for index := 1; index <= shares; index++ {
+		data := make([]byte, 0, mnemonicShareHeaderLen+len(entropy)+mnemonicShareChecksumLen)
+		data = append(data, mnemonicShareVersion)
+		data = append(data, identifier...)
+		data = append(data, byte(threshold), byte(index))
+		data = append(data, secretShares[byte(index)]...)
+		data = append(data, e2util.SHA256(data)[:mnemonicShareChecksumLen]...)
+		res[index-1] = bytesToWords(data)
+	}
+	return res, nil
+}
+
+// EntropyFromMnemonicShares recreates the entropy of a mnemonic from its shares.
+func EntropyFromMnemonicShares(shares []string) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares supplied")
+	}
+	var first *mnemonicShare
+	secretShares := make(map[byte][]byte, len(shares))
+	for i := range shares {
+		share, err := decodeMnemonicShare(shares[i])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("share %d", i+1))
+		}
+		if first == nil {
+			first = share
+		}
+		if !bytes.Equal(share.identifier, first.identifier) {
+			return nil, fmt.Errorf("share %d is from a different set of shares", i+1)
+		}
+		if share.threshold != first.threshold {
+			return nil, fmt.Errorf("share %d has a different threshold", i+1)
+		}
+		if _, exists := secretShares[share.index]; exists {
+			return nil, fmt.Errorf("share %d is a duplicate", i+1)
+		}
+		secretShares[share.index] = share.data
+	}
+	if len(secretShares) < int(first.threshold) {
+		return nil, fmt.Errorf("at least %d shares are required", first.threshold)
+	}
+	return ShamirCombine(secretShares)
+}
+
+// decodeMnemonicShare decodes a share, confirming its checksum.
+func decodeMnemonicShare(input string) (*mnemonicShare, error) {
+	words := strings.Fields(NormaliseMnemonic(input))
+	length := 0
+	for entropyLen := mnemonicShareMinEntropy; entropyLen <= mnemonicShareMaxEntropy; entropyLen += mnemonicShareEntropyDelta {
+		dataLen := mnemonicShareHeaderLen + entropyLen + mnemonicShareChecksumLen
+		if (dataLen*8+mnemonicShareBitsPerWord-1)/mnemonicShareBitsPerWord == len(words) {
+			length = dataLen
+		}
+	}
+	if length == 0 {
+		return nil, fmt.Errorf("invalid number of words %d", len(words))
+	}
+	data, err := wordsToBytes(words, length)
+	if err != nil {
+		return nil, err
+	}
+	checksumStart := len(data) - mnemonicShareChecksumLen
+	if !bytes.Equal(e2util.SHA256(data[:checksumStart])[:mnemonicShareChecksumLen], data[checksumStart:]) {
+		return nil, errors.New("checksum incorrect; please check the words of the share")
+	}
+	if data[0] != mnemonicShareVersion {
+		return nil, fmt.Errorf("unsupported version %d", data[0])
+	}
+	res := &mnemonicShare{
+		identifier: data[1:3],
+		threshold:  data[3],
+		index:      data[4],
+		data:       data[mnemonicShareHeaderLen:checksumStart],
+	}
+	if res.index == 0 {
+		return nil, errors.New("invalid index")
+	}
+	return res, nil
+}
+
+// bytesToWords encodes data as words from the BIP-39 word list, padding with zero bits.
+func bytesToWords(data []byte) string {
+	wordList := bip39.GetWordList()
+	numWords := (len(data)*8 + mnemonicShareBitsPerWord - 1) / mnemonicShareBitsPerWord
+	value := new(big.Int).SetBytes(data)
+	value.Lsh(value, uint(numWords*mnemonicShareBitsPerWord-len(data)*8))
+	mask := big.NewInt(1<<mnemonicShareBitsPerWord - 1)
+	words := make([]string, numWords)
+	for i := numWords - 1; i >= 0; i-- {
+		words[i] = wordList[new(big.Int).And(value, mask).Int64()]
+		value.Rsh(value, mnemonicShareBitsPerWord)
+	}
+	return strings.Join(words, " ")
+}
+
+// wordsToBytes decodes words from the BIP-39 word list to data of the given length.
+func wordsToBytes(words []string, length int) ([]byte, error) {
+	value := new(big.Int)
+	for _, word := range words {
+		index, exists := bip39.GetWordIndex(word)
+		if !exists {
+			return nil, fmt.Errorf("unknown word %q", word)
+		}
+		value.Lsh(value, mnemonicShareBitsPerWord)
+		value.Or(value, big.NewInt(int64(index)))
+	}
+	padding := uint(len(words)*mnemonicShareBitsPerWord - length*8)
+	if new(big.Int).And(value, big.NewInt(1<<padding-1)).Sign() != 0 {
+		return nil, errors.New("invalid padding; please check the words of the share")
+	}
+	value.Rsh(value, padding)
+	data := value.Bytes()
+	if len(data) > length {
+		return nil, errors.New("invalid data")
+	}
+	res := make([]byte, length)
+	copy(res[length-len(data):], data)
+	return res, nil
+}
@@ -0,0 +1,196 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMnemonicSharesRoundTrip(t *testing.T) {
+	for _, entropyLen := range []int{16, 20, 24, 28, 32} {
+		entropy := bytes.Repeat([]byte{0xa5}, entropyLen)
+		entropy[0] = byte(entropyLen)
+		shares, err := MnemonicSharesFromEntropy(entropy, 3, 5)
+		if err != nil {
+			t.Fatalf("%d: failed to create shares: %v", entropyLen, err)
+		}
+		if len(shares) != 5 {
+			t.Fatalf("%d: expected 5 shares, got %d", entropyLen, len(shares))
+		}
+		for _, subset := range subsets(5, 3) {
+			selected := make([]string, len(subset))
+			for i, x := range subset {
+				selected[i] = shares[x-1]
+			}
+			res, err := EntropyFromMnemonicShares(selected)
+			if err != nil {
+				t.Fatalf("%d: failed to recreate entropy from %v: %v", entropyLen, subset, err)
+			}
+			if !bytes.Equal(res, entropy) {
+				t.Fatalf("%d: shares %v recreated incorrect entropy", entropyLen, subset)
+			}
+		}
+
+		// Shares are accepted regardless of case and spacing.
+		res, err := EntropyFromMnemonicShares([]string{strings.ToUpper(shares[0]), "  " + shares[2], strings.Replace(shares[4], " ", "  ", -1)})
+		if err != nil || !bytes.Equal(res, entropy) {
+			t.Fatalf("%d: failed to recreate entropy from reformatted shares: %v", entropyLen, err)
+		}
+	}
+}
+
+// replaceWord replaces a word of a share with a different word from the BIP-39 word list.
+func replaceWord(share string, index int) string {
+	words := strings.Fields(share)
+	if words[index] == "abandon" {
+		words[index] = "ability"
+	} else {
+		words[index] = "abandon"
+	}
+	return strings.Join(words, " ")
+}
+
+func TestMnemonicSharesErrors(t *testing.T) {
+	entropy := bytes.Repeat([]byte{0x5a}, 32)
+	shares, err := MnemonicSharesFromEntropy(entropy, 3, 5)
+	if err != nil {
+		t.Fatalf("failed to create shares: %v", err)
+	}
+	otherShares, err := MnemonicSharesFromEntropy(entropy, 3, 5)
+	if err != nil {
+		t.Fatalf("failed to create shares: %v", err)
+	}
+	twoOfThree, err := MnemonicSharesFromEntropy(entropy, 2, 3)
+	if err != nil {
+		t.Fatalf("failed to create shares: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		shares []string
+		err    string
+	}{
+		{
+			name: "NoShares",
+			err:  "no shares supplied",
+		},
+		{
+			name:   "BelowThreshold",
+			shares: []string{shares[0], shares[1]},
+			err:    "at least 3 shares are required",
+		},
+		{
+			name:   "Duplicate",
+			shares: []string{shares[0], shares[1], shares[1]},
+			err:    "share 3 is a duplicate",
+		},
+		{
+			name:   "DuplicateMakesUpThreshold",
+			shares: []string{shares[0], shares[0], shares[1]},
+			err:    "share 2 is a duplicate",
+		},
+		{
+			name:   "CorruptWord",
+			shares: []string{shares[0], replaceWord(shares[1], 10), shares[2]},
+			err:    "share 2: checksum incorrect",
+		},
+		{
+			name:   "CorruptLastWord",
+			shares: []string{shares[0], shares[1], replaceWord(shares[2], len(strings.Fields(shares[2]))-1)},
+			err:    "share 3:",
+		},
+		{
+			name:   "UnknownWord",
+			shares: []string{shares[0], shares[1], strings.Replace(shares[2], strings.Fields(shares[2])[3], "notaword", 1)},
+			err:    `share 3: unknown word "notaword"`,
+		},
+		{
+			name:   "MissingWord",
+			shares: []string{shares[0], strings.Join(strings.Fields(shares[1])[1:], " "), shares[2]},
+			err:    "share 2: invalid number of words",
+		},
+		{
+			name:   "DifferentSet",
+			shares: []string{shares[0], shares[1], otherShares[2]},
+			err:    "share 3 is from a different set of shares",
+		},
+		{
+			name:   "DifferentSetAndThreshold",
+			shares: []string{twoOfThree[0], shares[1]},
+			err:    "share 2 is from a different set of shares",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := EntropyFromMnemonicShares(test.shares)
+			if err == nil {
+				t.Fatalf("expected error %q", test.err)
+			}
+			if !strings.HasPrefix(err.Error(), test.err) {
+				t.Fatalf("expected error %q, got %q", test.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestMnemonicSharesFromEntropyErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		entropy   []byte
+		threshold int
+		shares    int
+		err       string
+	}{
+		{
+			name:      "EntropyTooShort",
+			entropy:   make([]byte, 12),
+			threshold: 2,
+			shares:    3,
+			err:       "invalid entropy length",
+		},
+		{
+			name:      "EntropyBadLength",
+			entropy:   make([]byte, 18),
+			threshold: 2,
+			shares:    3,
+			err:       "invalid entropy length",
+		},
+		{
+			name:      "ThresholdOne",
+			entropy:   make([]byte, 16),
+			threshold: 1,
+			shares:    3,
+			err:       "threshold must be at least 2",
+		},
+		{
+			name:      "ThresholdTooHigh",
+			entropy:   make([]byte, 16),
+			threshold: 4,
+			shares:    3,
+			err:       "threshold cannot be more than the number of shares",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := MnemonicSharesFromEntropy(test.entropy, test.threshold, test.shares)
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/go-ecodec"
+	e2util "github.com/wealdtech/go-eth2-util"
+)
+
+// S3StoreParams are the parameters for an S3 wallet store.
+type S3StoreParams struct {
+	// Bucket is the name of the bucket.  If not supplied it is generated from the access key ID.
+	Bucket string
+	// Region is the region of the bucket.  Defaults to us-east-1.
+	Region string
+	// Endpoint is the endpoint of an S3-compatible service.  Defaults to Amazon S3.
+	Endpoint string
+	// PathStyle uses path-style rather than virtual host-style addressing for the bucket.
+	PathStyle bool
+	// Profile is the shared credentials profile.
+	Profile string
+	// AccessKeyID and SecretAccessKey are static credentials, used in preference to a profile.
+	AccessKeyID     string
+	SecretAccessKey string
+	// Passphrase encrypts the data held in the store.
+	Passphrase []byte
+}
+
+// S3Store is a wallet store held in S3 or an S3-compatible service.
+// It uses the same layout as github.com/wealdtech/go-eth2-wallet-store-s3, so can access wallets created with that store.
+type S3Store struct {
+	conn       *s3.S3
+	bucket     string
+	passphrase []byte
+
+	listErrMu sync.Mutex
+	listErr   error
+}
+
+// NewS3Store creates a new S3 store, creating its bucket if it does not exist.
+func NewS3Store(params *S3StoreParams) (*S3Store, error) {
+	config := aws.NewConfig().WithRegion("us-east-1")
+	if params.Region != "" {
+		config = config.WithRegion(params.Region)
+	}
+	if params.Endpoint != "" {
+		config = config.WithEndpoint(params.Endpoint)
+	}
+	if params.PathStyle {
+		config = config.WithS3ForcePathStyle(true)
+	}
+	if params.AccessKeyID != "" {
+		if params.SecretAccessKey == "" {
+			return nil, errors.New("secret access key required with access key ID")
+		}
+		config = config.WithCredentials(credentials.NewStaticCredentials(params.AccessKeyID, params.SecretAccessKey, ""))
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		Profile:           params.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create session")
+	}
+
+	bucket := params.Bucket
+	if bucket == "" {
+		creds, err := sess.Config.Credentials.Get()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain credentials")
+		}
+		// Generate the bucket name as per go-eth2-wallet-store-s3.
+		hash := e2util.SHA256([]byte(fmt.Sprintf("Ethereum 2 wallet:%s", creds.AccessKeyID)))
+		bucket = hex.EncodeToString(hash)[:63]
+	}
+
+	store := &S3Store{
+		conn:       s3.New(sess),
+		bucket:     bucket,
+		passphrase: params.Passphrase,
+	}
+	if err := store.ensureBucket(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// ensureBucket checks that the bucket can be reached and listed, creating it if it does not exist.
+// Listing is checked up front because later listings cannot return errors through their channels, and would
+// otherwise make an inaccessible store look empty.
+func (s *S3Store) ensureBucket() error {
+	_, err := s.conn.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(s.bucket)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || (aerr.Code() != "NotFound" && aerr.Code() != s3.ErrCodeNoSuchBucket) {
+			return errors.Wrap(err, fmt.Sprintf("unable to access bucket %s", s.bucket))
+		}
+		if _, err := s.conn.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(s.bucket)}); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("unable to create bucket %s", s.bucket))
+		}
+		if err := s.conn.WaitUntilBucketExists(&s3.HeadBucketInput{Bucket: aws.String(s.bucket)}); err != nil {
+			return errors.Wrap(err, "failed to confirm bucket creation")
+		}
+	}
+	if _, err := s.conn.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		MaxKeys: aws.Int64(1),
+	}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to list bucket %s", s.bucket))
+	}
+	return nil
+}
+
+// Name returns the name of this store.
+func (s *S3Store) Name() string {
+	return "s3"
+}
+
+// Location returns the bucket of this store.
+func (s *S3Store) Location() string {
+	return s.bucket
+}
+
+// StoreWallet stores wallet-level data.
+func (s *S3Store) StoreWallet(walletID uuid.UUID, walletName string, data []byte) error {
+	data, err := s.encryptIfRequired(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt wallet")
+	}
+	return s.put(walletHeaderKey(walletID), data)
+}
+
+// RetrieveWallet retrieves wallet-level data for a wallet with a given name.
+func (s *S3Store) RetrieveWallet(walletName string) ([]byte, error) {
+	for data := range s.RetrieveWallets() {
+		info := &struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(data, info); err == nil && info.Name == walletName {
+			return data, nil
+		}
+	}
+	if err := s.ListError(); err != nil {
+		return nil, errors.Wrap(err, "failed to list wallets")
+	}
+	return nil, errors.New("wallet not found")
+}
+
+// RetrieveWalletByID retrieves wallet-level data for a wallet with a given ID.
+func (s *S3Store) RetrieveWalletByID(walletID uuid.UUID) ([]byte, error) {
+	data, err := s.get(walletHeaderKey(walletID))
+	if err != nil {
+		return nil, errors.New("wallet not found")
+	}
+	return s.decryptIfRequired(data)
+}
+
+// RetrieveWallets retrieves wallet-level data for all wallets.
+func (s *S3Store) RetrieveWallets() <-chan []byte {
+	ch := make(chan []byte, 1024)
+	go func() {
+		walletIDs, err := s.WalletIDs()
+		s.setListError(err)
+		for _, walletID := range walletIDs {
+			data, err := s.RetrieveWalletByID(walletID)
+			if err != nil {
+				continue
+			}
+			ch <- data
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// WalletIDs returns the IDs of all wallets in the store, including those whose data cannot be decrypted.
+func (s *S3Store) WalletIDs() ([]uuid.UUID, error) {
+	walletIDs := make([]uuid.UUID, 0)
+	err := s.conn.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, prefix := range page.CommonPrefixes {
+			walletID, err := uuid.Parse(strings.TrimSuffix(*prefix.Prefix, "/"))
+			if err != nil {
+				continue
+			}
+			walletIDs = append(walletIDs, walletID)
+		}
+		return true
+	})
+	return walletIDs, err
+}
+
+// StoreAccount stores an account.
+func (s *S3Store) StoreAccount(walletID uuid.UUID, accountID uuid.UUID, data []byte) error {
+	if _, err := s.get(walletHeaderKey(walletID)); err != nil {
+		return errors.New("unknown wallet")
+	}
+	data, err := s.encryptIfRequired(data)
+	if err != nil {
+		return err
+	}
+	return s.put(accountKey(walletID, accountID), data)
+}
+
+// RetrieveAccount retrieves account-level data for a given account.
+func (s *S3Store) RetrieveAccount(walletID uuid.UUID, accountID uuid.UUID) ([]byte, error) {
+	data, err := s.get(accountKey(walletID, accountID))
+	if err != nil {
+		return nil, errors.New("account not found")
+	}
+	return s.decryptIfRequired(data)
+}
+
+// RetrieveAccounts retrieves all account-level data for a wallet.
+func (s *S3Store) RetrieveAccounts(walletID uuid.UUID) <-chan []byte {
+	ch := make(chan []byte, 1024)
+	go func() {
+		accountIDs := make([]uuid.UUID, 0)
+		err := s.conn.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+			Bucket: aws.String(s.bucket),
+			Prefix: aws.String(walletID.String() + "/"),
+		}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, item := range page.Contents {
+				accountID, err := uuid.Parse(strings.TrimPrefix(*item.Key, walletID.String()+"/"))
+				if err != nil || accountID == walletID {
+					// Not an account.
+					continue
+				}
+				accountIDs = append(accountIDs, accountID)
+			}
+			return true
+		})
+		s.setListError(err)
+		for _, accountID := range accountIDs {
+			data, err := s.RetrieveAccount(walletID, accountID)
+			if err != nil {
+				continue
+			}
+			ch <- data
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// StoreAccountsIndex stores the index of accounts for a given wallet.
+func (s *S3Store) StoreAccountsIndex(walletID uuid.UUID, data []byte) error {
+	// Do not encrypt empty index.
+	if len(data) != 2 {
+		var err error
+		data, err = s.encryptIfRequired(data)
+		if err != nil {
+			return err
+		}
+	}
+	return s.put(indexKey(walletID), data)
+}
+
+// RetrieveAccountsIndex retrieves the index of accounts for a given wallet.
+func (s *S3Store) RetrieveAccountsIndex(walletID uuid.UUID) ([]byte, error) {
+	data, err := s.get(indexKey(walletID))
+	if err != nil {
+		return nil, err
+	}
+	// Do not decrypt empty index.
+	if len(data) == 2 {
+		return data, nil
+	}
+	return s.decryptIfRequired(data)
+}
+
+// RemoveWallet removes a wallet, along with its accounts and index, from the store.
+func (s *S3Store) RemoveWallet(walletID uuid.UUID) error {
+	keys := make([]string, 0)
+	if err := s.conn.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(walletID.String() + "/"),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, item := range page.Contents {
+			keys = append(keys, *item.Key)
+		}
+		return true
+	}); err != nil {
+		return errors.Wrap(err, "failed to list wallet")
+	}
+	for _, key := range keys {
+		if _, err := s.conn.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		}); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to remove %s", key))
+		}
+	}
+	return nil
+}
+
+// ListError returns the error from the most recent listing of wallets or accounts, if any.  Listings return their
+// results over channels, so this allows callers to tell an inaccessible store from an empty one.
+func (s *S3Store) ListError() error {
+	s.listErrMu.Lock()
+	defer s.listErrMu.Unlock()
+	return s.listErr
+}
+
+func (s *S3Store) setListError(err error) {
+	s.listErrMu.Lock()
+	s.listErr = err
+	s.listErrMu.Unlock()
+}
+
+func (s *S3Store) put(key string, data []byte) error {
+	if _, err := s.conn.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to store %s", key))
+	}
+	return nil
+}
+
+func (s *S3Store) get(key string) ([]byte, error) {
+	resp, err := s.conn.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *S3Store) encryptIfRequired(data []byte) ([]byte, error) {
+	if len(s.passphrase) == 0 {
+		return data, nil
+	}
+	return ecodec.Encrypt(data, s.passphrase)
+}
+
+func (s *S3Store) decryptIfRequired(data []byte) ([]byte, error) {
+	if len(s.passphrase) == 0 || len(data) == 0 {
+		return data, nil
+	}
+	return ecodec.Decrypt(data, s.passphrase)
+}
+
+func walletHeaderKey(walletID uuid.UUID) string {
+	return fmt.Sprintf("%s/%s", walletID, walletID)
+}
+
+func accountKey(walletID uuid.UUID, accountID uuid.UUID) string {
+	return fmt.Sprintf("%s/%s", walletID, accountID)
+}
+
+func indexKey(walletID uuid.UUID) string {
+	return fmt.Sprintf("%s/index", walletID)
+}
@@ -0,0 +1,314 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible service, handling path-style requests only.
+type fakeS3 struct {
+	mu          sync.Mutex
+	buckets     map[string]map[string][]byte
+	hosts       map[string]bool
+	denyListing bool
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		buckets: make(map[string]map[string][]byte),
+		hosts:   make(map[string]bool),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hosts[r.Host] = true
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucketName := parts[0]
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	bucket, exists := f.buckets[bucketName]
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case key == "" && r.Method == http.MethodPut:
+		if !exists {
+			f.buckets[bucketName] = make(map[string][]byte)
+		}
+	case key == "" && r.Method == http.MethodGet:
+		if !exists {
+			writeFakeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+			return
+		}
+		if f.denyListing {
+			writeFakeS3Error(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		f.list(w, bucket, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+	case !exists:
+		writeFakeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+	case r.Method == http.MethodPut:
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeFakeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		bucket[key] = data
+	case r.Method == http.MethodGet:
+		data, exists := bucket[key]
+		if !exists {
+			writeFakeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeFakeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, bucket map[string][]byte, prefix string, delimiter string) {
+	type content struct {
+		Key  string `xml:"Key"`
+		Size int    `xml:"Size"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	res := struct {
+		XMLName        xml.Name       `xml:"ListBucketResult"`
+		Prefix         string         `xml:"Prefix"`
+		KeyCount       int            `xml:"KeyCount"`
+		IsTruncated    bool           `xml:"IsTruncated"`
+		Contents       []content      `xml:"Contents"`
+		CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+	}{
+		Prefix: prefix,
+	}
+	keys := make([]string, 0, len(bucket))
+	for key := range bucket {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix)
+		if delimiter != "" && strings.Contains(rest, delimiter) {
+			common := prefix + rest[:strings.Index(rest, delimiter)+len(delimiter)]
+			if !seen[common] {
+				seen[common] = true
+				res.CommonPrefixes = append(res.CommonPrefixes, commonPrefix{Prefix: common})
+			}
+			continue
+		}
+		res.Contents = append(res.Contents, content{Key: key, Size: len(bucket[key])})
+	}
+	res.KeyCount = len(res.Contents) + len(res.CommonPrefixes)
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func writeFakeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func newTestS3Store(server *httptest.Server, bucket string, passphrase []byte) (*S3Store, error) {
+	return NewS3Store(&S3StoreParams{
+		Bucket:          bucket,
+		Endpoint:        server.URL,
+		PathStyle:       true,
+		AccessKeyID:     "AKIDTEST",
+		SecretAccessKey: "secret",
+		Passphrase:      passphrase,
+	})
+}
+
+func TestS3StoreBucketCreation(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := newTestS3Store(server, "", nil)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if len(store.Location()) != 63 {
+		t.Fatalf("unexpected generated bucket name %q", store.Location())
+	}
+	if _, exists := fake.buckets[store.Location()]; !exists {
+		t.Fatal("bucket not created")
+	}
+
+	// Requests are path-style, so always address the endpoint's host rather than a bucket subdomain.
+	serverHost := strings.TrimPrefix(server.URL, "http://")
+	for host := range fake.hosts {
+		if host != serverHost {
+			t.Fatalf("request sent to %s rather than %s", host, serverHost)
+		}
+	}
+
+	// Opening again uses the existing bucket.
+	if _, err := newTestS3Store(server, "", nil); err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+}
+
+func TestS3StoreListError(t *testing.T) {
+	fake := newFakeS3()
+	fake.buckets["denied"] = make(map[string][]byte)
+	fake.denyListing = true
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	if _, err := newTestS3Store(server, "denied", nil); err == nil || !strings.Contains(err.Error(), "unable to list bucket") {
+		t.Fatalf("expected list failure, got %v", err)
+	}
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		passphrase []byte
+	}{
+		{
+			name: "Plain",
+		},
+		{
+			name:       "Encrypted",
+			passphrase: []byte("store secret"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newFakeS3()
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			store, err := newTestS3Store(server, "wallets", test.passphrase)
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+
+			walletID := uuid.New()
+			walletData := []byte(fmt.Sprintf(`{"uuid":%q,"name":"Test wallet","type":"non-deterministic","version":1}`, walletID))
+			if err := store.StoreWallet(walletID, "Test wallet", walletData); err != nil {
+				t.Fatalf("failed to store wallet: %v", err)
+			}
+			accountID := uuid.New()
+			accountData := []byte(fmt.Sprintf(`{"uuid":%q,"name":"Test account"}`, accountID))
+			if err := store.StoreAccount(walletID, accountID, accountData); err != nil {
+				t.Fatalf("failed to store account: %v", err)
+			}
+			indexData := []byte(fmt.Sprintf(`[{"uuid":%q,"name":"Test account"}]`, accountID))
+			if err := store.StoreAccountsIndex(walletID, indexData); err != nil {
+				t.Fatalf("failed to store index: %v", err)
+			}
+			if err := store.StoreAccount(uuid.New(), uuid.New(), accountData); err == nil {
+				t.Fatal("stored account in unknown wallet")
+			}
+
+			// Data at rest is encrypted only when a passphrase is supplied.
+			stored := fake.buckets["wallets"][walletHeaderKey(walletID)]
+			if encrypted := !bytes.Equal(stored, walletData); encrypted != (test.passphrase != nil) {
+				t.Fatalf("unexpected encryption state %v of stored wallet", encrypted)
+			}
+
+			data, err := store.RetrieveWallet("Test wallet")
+			if err != nil || !bytes.Equal(data, walletData) {
+				t.Fatalf("failed to retrieve wallet by name: %v", err)
+			}
+			data, err = store.RetrieveWalletByID(walletID)
+			if err != nil || !bytes.Equal(data, walletData) {
+				t.Fatalf("failed to retrieve wallet by ID: %v", err)
+			}
+			if _, err := store.RetrieveWallet("Unknown"); err == nil {
+				t.Fatal("retrieved unknown wallet")
+			}
+			wallets := 0
+			for range store.RetrieveWallets() {
+				wallets++
+			}
+			if wallets != 1 {
+				t.Fatalf("expected 1 wallet, found %d", wallets)
+			}
+
+			data, err = store.RetrieveAccount(walletID, accountID)
+			if err != nil || !bytes.Equal(data, accountData) {
+				t.Fatalf("failed to retrieve account: %v", err)
+			}
+			accounts := make([][]byte, 0)
+			for data := range store.RetrieveAccounts(walletID) {
+				accounts = append(accounts, data)
+			}
+			if len(accounts) != 1 || !bytes.Equal(accounts[0], accountData) {
+				t.Fatalf("unexpected accounts %v", accounts)
+			}
+			data, err = store.RetrieveAccountsIndex(walletID)
+			if err != nil || !bytes.Equal(data, indexData) {
+				t.Fatalf("failed to retrieve index: %v", err)
+			}
+			if err := store.ListError(); err != nil {
+				t.Fatalf("unexpected list error: %v", err)
+			}
+
+			if test.passphrase != nil {
+				// The same bucket cannot be read with a different passphrase.
+				other, err := newTestS3Store(server, "wallets", []byte("wrong"))
+				if err != nil {
+					t.Fatalf("failed to open store: %v", err)
+				}
+				if _, err := other.RetrieveWalletByID(walletID); err == nil {
+					t.Fatal("retrieved wallet with wrong passphrase")
+				}
+			}
+
+			if err := store.RemoveWallet(walletID); err != nil {
+				t.Fatalf("failed to remove wallet: %v", err)
+			}
+			if len(fake.buckets["wallets"]) != 0 {
+				t.Fatalf("objects remain after removing wallet: %d", len(fake.buckets["wallets"]))
+			}
+			if _, err := store.RetrieveWalletByID(walletID); err == nil {
+				t.Fatal("retrieved removed wallet")
+			}
+		})
+	}
+}
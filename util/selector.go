@@ -0,0 +1,283 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SelectorItem is the information about an account against which a selector is matched.
+type SelectorItem struct {
+	WalletName  string
+	AccountName string
+	Labels      map[string]string
+}
+
+// Selector selects accounts according to an expression such as 'label:region=eu AND (wallet:Validators OR NOT label:cohort)'.
+// Terms are "wallet:<regex>" and "account:<regex>", which must match the whole name, and "label:<key>",
+// "label:<key>=<value>" and "label:<key>!=<value>".  Terms are combined with NOT, AND and OR, in order of
+// precedence, and parentheses.  Double quotes allow spaces within a term, for example wallet:"Personal wallet".
+type Selector struct {
+	root selectorNode
+}
+
+type selectorNode interface {
+	matches(item *SelectorItem) bool
+}
+
+type selectorNot struct {
+	node selectorNode
+}
+
+func (n *selectorNot) matches(item *SelectorItem) bool {
+	return !n.node.matches(item)
+}
+
+type selectorAnd struct {
+	nodes []selectorNode
+}
+
+func (n *selectorAnd) matches(item *SelectorItem) bool {
+	for _, node := range n.nodes {
+		if !node.matches(item) {
+			return false
+		}
+	}
+	return true
+}
+
+type selectorOr struct {
+	nodes []selectorNode
+}
+
+func (n *selectorOr) matches(item *SelectorItem) bool {
+	for _, node := range n.nodes {
+		if node.matches(item) {
+			return true
+		}
+	}
+	return false
+}
+
+type selectorName struct {
+	wallet bool
+	re     *regexp.Regexp
+}
+
+func (n *selectorName) matches(item *SelectorItem) bool {
+	if n.wallet {
+		return n.re.MatchString(item.WalletName)
+	}
+	return n.re.MatchString(item.AccountName)
+}
+
+type selectorLabel struct {
+	key      string
+	value    string
+	hasValue bool
+	negate   bool
+}
+
+func (n *selectorLabel) matches(item *SelectorItem) bool {
+	value, exists := item.Labels[n.key]
+	if !n.hasValue {
+		return exists
+	}
+	if n.negate {
+		return !exists || value != n.value
+	}
+	return exists && value == n.value
+}
+
+// ParseSelector parses a selector expression.
+func ParseSelector(input string) (*Selector, error) {
+	tokens, err := selectorTokens(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty selector")
+	}
+	parser := &selectorParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos != len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected %q in selector", parser.tokens[parser.pos])
+	}
+	return &Selector{root: root}, nil
+}
+
+// Matches returns true if the item is selected.
+func (s *Selector) Matches(item *SelectorItem) bool {
+	return s.root.matches(item)
+}
+
+// selectorTokens splits a selector expression in to parentheses, operators and terms.
+func selectorTokens(input string) ([]string, error) {
+	tokens := make([]string, 0)
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+			current.WriteRune(r)
+		case r == '(' || r == ')':
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n':
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unterminated quote in selector")
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+type selectorParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *selectorParser) peekOperator(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *selectorParser) parseOr() (selectorNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []selectorNode{node}
+	for p.peekOperator("OR") {
+		p.pos++
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &selectorOr{nodes: nodes}, nil
+}
+
+func (p *selectorParser) parseAnd() (selectorNode, error) {
+	node, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []selectorNode{node}
+	for p.peekOperator("AND") {
+		p.pos++
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &selectorAnd{nodes: nodes}, nil
+}
+
+func (p *selectorParser) parseNot() (selectorNode, error) {
+	if p.peekOperator("NOT") {
+		p.pos++
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &selectorNot{node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *selectorParser) parsePrimary() (selectorNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("selector ends unexpectedly")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch {
+	case token == "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, errors.New("missing ) in selector")
+		}
+		p.pos++
+		return node, nil
+	case token == ")":
+		return nil, errors.New("unexpected ) in selector")
+	case strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR"):
+		return nil, fmt.Errorf("unexpected %s in selector", token)
+	}
+	return parseSelectorTerm(token)
+}
+
+// parseSelectorTerm parses a single field:value term.
+func parseSelectorTerm(token string) (selectorNode, error) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid selector term %q", token)
+	}
+	switch strings.ToLower(parts[0]) {
+	case "wallet", "account":
+		// Group the expression so that alternations are anchored as a whole.
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", parts[1]))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid regular expression in selector term %q", token))
+		}
+		return &selectorName{wallet: strings.EqualFold(parts[0], "wallet"), re: re}, nil
+	case "label":
+		if strings.HasPrefix(parts[1], "=") || strings.HasPrefix(parts[1], "!=") {
+			return nil, fmt.Errorf("missing label key in selector term %q", token)
+		}
+		if strings.Contains(parts[1], "!=") {
+			kv := strings.SplitN(parts[1], "!=", 2)
+			return &selectorLabel{key: kv[0], value: kv[1], hasValue: true, negate: true}, nil
+		}
+		if strings.Contains(parts[1], "=") {
+			kv := strings.SplitN(parts[1], "=", 2)
+			return &selectorLabel{key: kv[0], value: kv[1], hasValue: true}, nil
+		}
+		return &selectorLabel{key: parts[1]}, nil
+	default:
+		return nil, fmt.Errorf("unknown selector field %q", parts[0])
+	}
+}
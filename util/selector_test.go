@@ -0,0 +1,244 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	items := map[string]*SelectorItem{
+		"eu1": {
+			WalletName:  "Validators",
+			AccountName: "1",
+			Labels:      map[string]string{"region": "eu", "cohort": "a"},
+		},
+		"eu2": {
+			WalletName:  "Validators",
+			AccountName: "2",
+			Labels:      map[string]string{"region": "eu"},
+		},
+		"us3": {
+			WalletName:  "Validators",
+			AccountName: "3",
+			Labels:      map[string]string{"region": "us", "cohort": "b"},
+		},
+		"personal": {
+			WalletName:  "Personal wallet",
+			AccountName: "Operations",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		selector string
+		selected []string
+	}{
+		{
+			name:     "Wallet",
+			selector: "wallet:Validators",
+			selected: []string{"eu1", "eu2", "us3"},
+		},
+		{
+			name:     "WalletWholeName",
+			selector: "wallet:Valid",
+			selected: []string{},
+		},
+		{
+			name:     "AccountRegex",
+			selector: "account:[12]",
+			selected: []string{"eu1", "eu2"},
+		},
+		{
+			name:     "AlternationAnchored",
+			selector: "account:Op|1",
+			selected: []string{"eu1"},
+		},
+		{
+			name:     "QuotedTerm",
+			selector: `wallet:"Personal wallet"`,
+			selected: []string{"personal"},
+		},
+		{
+			name:     "QuotedRegex",
+			selector: `wallet:"Personal .*"`,
+			selected: []string{"personal"},
+		},
+		{
+			name:     "LabelPresent",
+			selector: "label:cohort",
+			selected: []string{"eu1", "us3"},
+		},
+		{
+			name:     "LabelValue",
+			selector: "label:region=eu",
+			selected: []string{"eu1", "eu2"},
+		},
+		{
+			name:     "LabelNotValue",
+			selector: "label:region!=eu",
+			selected: []string{"us3", "personal"},
+		},
+		{
+			name:     "LabelNotValueMissingKey",
+			selector: "label:cohort!=a",
+			selected: []string{"eu2", "us3", "personal"},
+		},
+		{
+			name:     "Not",
+			selector: "NOT label:cohort",
+			selected: []string{"eu2", "personal"},
+		},
+		{
+			name:     "DoubleNot",
+			selector: "NOT NOT label:cohort",
+			selected: []string{"eu1", "us3"},
+		},
+		{
+			name:     "AndBindsTighterThanOr",
+			selector: "label:region=us OR label:region=eu AND label:cohort",
+			selected: []string{"eu1", "us3"},
+		},
+		{
+			name:     "NotBindsTighterThanAnd",
+			selector: "NOT label:cohort AND wallet:Validators",
+			selected: []string{"eu2"},
+		},
+		{
+			name:     "Parentheses",
+			selector: "(label:region=us OR label:region=eu) AND label:cohort",
+			selected: []string{"eu1", "us3"},
+		},
+		{
+			name:     "ParenthesesChangePrecedence",
+			selector: "label:region=eu AND (label:cohort=b OR NOT label:cohort)",
+			selected: []string{"eu2"},
+		},
+		{
+			name:     "NotParentheses",
+			selector: "NOT (wallet:Validators AND label:region=eu)",
+			selected: []string{"us3", "personal"},
+		},
+		{
+			name:     "OperatorCase",
+			selector: "label:region=eu and not label:cohort",
+			selected: []string{"eu2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, err := ParseSelector(test.selector)
+			if err != nil {
+				t.Fatalf("failed to parse selector: %v", err)
+			}
+			expected := make(map[string]bool)
+			for _, name := range test.selected {
+				expected[name] = true
+			}
+			for name, item := range items {
+				if selector.Matches(item) != expected[name] {
+					t.Errorf("%s: expected selected %v", name, expected[name])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectorErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		err      string
+	}{
+		{
+			name:     "Empty",
+			selector: "  ",
+			err:      "empty selector",
+		},
+		{
+			name:     "UnterminatedQuote",
+			selector: `wallet:"Personal wallet`,
+			err:      "unterminated quote in selector",
+		},
+		{
+			name:     "MissingClose",
+			selector: "(wallet:a OR wallet:b",
+			err:      "missing ) in selector",
+		},
+		{
+			name:     "UnexpectedClose",
+			selector: "wallet:a)",
+			err:      `unexpected ")" in selector`,
+		},
+		{
+			name:     "LeadingClose",
+			selector: ") wallet:a",
+			err:      "unexpected ) in selector",
+		},
+		{
+			name:     "TrailingOperator",
+			selector: "wallet:a AND",
+			err:      "selector ends unexpectedly",
+		},
+		{
+			name:     "LeadingOperator",
+			selector: "OR wallet:a",
+			err:      "unexpected OR in selector",
+		},
+		{
+			name:     "MissingOperator",
+			selector: "wallet:a wallet:b",
+			err:      `unexpected "wallet:b" in selector`,
+		},
+		{
+			name:     "UnknownField",
+			selector: "colour:red",
+			err:      `unknown selector field "colour"`,
+		},
+		{
+			name:     "MissingValue",
+			selector: "wallet:",
+			err:      `invalid selector term "wallet:"`,
+		},
+		{
+			name:     "MissingField",
+			selector: "Validators",
+			err:      `invalid selector term "Validators"`,
+		},
+		{
+			name:     "InvalidRegex",
+			selector: "account:[",
+			err:      `invalid regular expression in selector term "account:["`,
+		},
+		{
+			name:     "MissingLabelKey",
+			selector: "label:=eu",
+			err:      `missing label key in selector term "label:=eu"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSelector(test.selector)
+			if err == nil {
+				t.Fatalf("expected error %q", test.err)
+			}
+			if !strings.HasPrefix(err.Error(), test.err) {
+				t.Fatalf("expected error %q, got %q", test.err, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// gf256Exp and gf256Log are exponent and logarithm tables for GF(2^8) with the AES polynomial and generator 3.
+var gf256Exp [510]byte
+var gf256Log [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gf256Exp[i] = x
+		gf256Exp[i+255] = x
+		gf256Log[x] = byte(i)
+		// Multiply by the generator 3, being x*2 ^ x.
+		x2 := x << 1
+		if x&0x80 != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+}
+
+func gf256Mul(a byte, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf256Exp[int(gf256Log[a])+int(gf256Log[b])]
+}
+
+func gf256Div(a byte, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gf256Exp[int(gf256Log[a])+255-int(gf256Log[b])]
+}
+
+// ShamirSplit splits a secret in to the given number of shares using Shamir's secret sharing over GF(2^8), any
+// threshold of which can recreate the secret.  It returns the shares indexed by their x coordinate, starting at 1.
+// Unlike SLIP-39, the secret is held directly at x=0 with no digest share, so shares are not interchangeable with
+// SLIP-39 implementations.
+func ShamirSplit(secret []byte, threshold int, shares int) (map[byte][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("no secret supplied")
+	}
+	if threshold < 1 {
+		return nil, errors.New("threshold must be at least 1")
+	}
+	if threshold > shares {
+		return nil, errors.New("threshold cannot be more than the number of shares")
+	}
+	if shares > 255 {
+		return nil, errors.New("no more than 255 shares are allowed")
+	}
+
+	// Each byte of the secret is the constant term of its own random polynomial of degree threshold-1.
+	coefficients := make([]byte, len(secret)*(threshold-1))
+	if _, err := rand.Read(coefficients); err != nil {
+		return nil, errors.Wrap(err, "failed to generate coefficients")
+	}
+
+	res := make(map[byte][]byte, shares)
+	for x := 1; x <= shares; x++ {
+		share := make([]byte, len(secret))
+		for i := range secret {
+			// Horner's method, from the highest coefficient down.
+			y := byte(0)
+			for j := threshold - 2; j >= 0; j-- {
+				y = gf256Mul(y, byte(x)) ^ coefficients[i*(threshold-1)+j]
+			}
+			share[i] = gf256Mul(y, byte(x)) ^ secret[i]
+		}
+		res[byte(x)] = share
+	}
+	return res, nil
+}
+
+// ShamirCombine recreates a secret from shares indexed by their x coordinate.  It cannot detect if too few shares
+// are supplied, so the result should be verified by the caller.
+func ShamirCombine(shares map[byte][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares supplied")
+	}
+	length := -1
+	for x, share := range shares {
+		if x == 0 {
+			return nil, errors.New("invalid share index 0")
+		}
+		if length == -1 {
+			length = len(share)
+		}
+		if len(share) != length {
+			return nil, fmt.Errorf("share %d has a different length", x)
+		}
+	}
+
+	// Lagrange interpolation at x=0.
+	secret := make([]byte, length)
+	for xi, share := range shares {
+		basis := byte(1)
+		for xj := range shares {
+			if xj == xi {
+				continue
+			}
+			basis = gf256Mul(basis, gf256Div(xj, xj^xi))
+		}
+		for i := range secret {
+			secret[i] ^= gf256Mul(share[i], basis)
+		}
+	}
+	return secret, nil
+}
@@ -0,0 +1,210 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testShamirSecret = []byte{
+	0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+	0xf0, 0xe1, 0xd2, 0xc3, 0xb4, 0xa5, 0x96, 0x87, 0x78, 0x69, 0x5a, 0x4b, 0x3c, 0x2d, 0x1e, 0xff,
+}
+
+// subsets returns all subsets of the given size of the indices 1 to n.
+func subsets(n int, size int) [][]byte {
+	res := make([][]byte, 0)
+	var build func(start int, current []byte)
+	build = func(start int, current []byte) {
+		if len(current) == size {
+			res = append(res, append([]byte{}, current...))
+			return
+		}
+		for i := start; i <= n; i++ {
+			build(i+1, append(current, byte(i)))
+		}
+	}
+	build(1, []byte{})
+	return res
+}
+
+func TestShamirRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		shares    int
+	}{
+		{
+			name:      "1of1",
+			threshold: 1,
+			shares:    1,
+		},
+		{
+			name:      "1of3",
+			threshold: 1,
+			shares:    3,
+		},
+		{
+			name:      "2of3",
+			threshold: 2,
+			shares:    3,
+		},
+		{
+			name:      "3of5",
+			threshold: 3,
+			shares:    5,
+		},
+		{
+			name:      "5of5",
+			threshold: 5,
+			shares:    5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shares, err := ShamirSplit(testShamirSecret, test.threshold, test.shares)
+			if err != nil {
+				t.Fatalf("failed to split: %v", err)
+			}
+			if len(shares) != test.shares {
+				t.Fatalf("expected %d shares, got %d", test.shares, len(shares))
+			}
+
+			// Every combination of threshold shares recreates the secret.
+			for _, subset := range subsets(test.shares, test.threshold) {
+				selected := make(map[byte][]byte, len(subset))
+				for _, x := range subset {
+					selected[x] = shares[x]
+				}
+				secret, err := ShamirCombine(selected)
+				if err != nil {
+					t.Fatalf("failed to combine %v: %v", subset, err)
+				}
+				if !bytes.Equal(secret, testShamirSecret) {
+					t.Fatalf("shares %v recreated incorrect secret", subset)
+				}
+			}
+
+			// No combination of fewer shares does.
+			if test.threshold > 1 {
+				for _, subset := range subsets(test.shares, test.threshold-1) {
+					selected := make(map[byte][]byte, len(subset))
+					for _, x := range subset {
+						selected[x] = shares[x]
+					}
+					secret, err := ShamirCombine(selected)
+					if err != nil {
+						t.Fatalf("failed to combine %v: %v", subset, err)
+					}
+					if bytes.Equal(secret, testShamirSecret) {
+						t.Fatalf("shares %v below threshold recreated secret", subset)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestShamirCorruptShare(t *testing.T) {
+	shares, err := ShamirSplit(testShamirSecret, 2, 3)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	shares[1][0] ^= 0x01
+	secret, err := ShamirCombine(map[byte][]byte{1: shares[1], 2: shares[2]})
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if bytes.Equal(secret, testShamirSecret) {
+		t.Fatal("corrupt share recreated secret")
+	}
+}
+
+func TestShamirErrors(t *testing.T) {
+	splitTests := []struct {
+		name      string
+		secret    []byte
+		threshold int
+		shares    int
+		err       string
+	}{
+		{
+			name:      "NoSecret",
+			threshold: 2,
+			shares:    3,
+			err:       "no secret supplied",
+		},
+		{
+			name:      "ThresholdZero",
+			secret:    testShamirSecret,
+			threshold: 0,
+			shares:    3,
+			err:       "threshold must be at least 1",
+		},
+		{
+			name:      "ThresholdTooHigh",
+			secret:    testShamirSecret,
+			threshold: 4,
+			shares:    3,
+			err:       "threshold cannot be more than the number of shares",
+		},
+		{
+			name:      "TooManyShares",
+			secret:    testShamirSecret,
+			threshold: 2,
+			shares:    256,
+			err:       "no more than 255 shares are allowed",
+		},
+	}
+	for _, test := range splitTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ShamirSplit(test.secret, test.threshold, test.shares)
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+
+	combineTests := []struct {
+		name   string
+		shares map[byte][]byte
+		err    string
+	}{
+		{
+			name: "NoShares",
+			err:  "no shares supplied",
+		},
+		{
+			name:   "IndexZero",
+			shares: map[byte][]byte{0: {0x01}, 1: {0x02}},
+			err:    "invalid share index 0",
+		},
+		{
+			name:   "DifferentLengths",
+			shares: map[byte][]byte{1: {0x01}, 2: {0x02, 0x03}},
+			err:    "has a different length",
+		},
+	}
+	for _, test := range combineTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ShamirCombine(test.shares)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
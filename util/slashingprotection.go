@@ -0,0 +1,76 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FilterSlashingProtection filters EIP-3076 slashing protection interchange data to the given public keys.
+// Fields other than the public keys are retained as supplied.  It returns the filtered data and the number of
+// public keys for which records were found.
+func FilterSlashingProtection(data []byte, pubKeys [][]byte) ([]byte, int, error) {
+	interchange := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &interchange); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to parse slashing protection data")
+	}
+	if _, exists := interchange["metadata"]; !exists {
+		return nil, 0, errors.New("slashing protection data has no metadata")
+	}
+	records := make([]json.RawMessage, 0)
+	if rawRecords, exists := interchange["data"]; exists {
+		if err := json.Unmarshal(rawRecords, &records); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to parse slashing protection records")
+		}
+	}
+
+	wanted := make(map[string]bool, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		wanted[fmt.Sprintf("%#x", pubKey)] = true
+	}
+	filtered := make([]json.RawMessage, 0)
+	// A key may have more than one record, so count the keys found rather than the records.
+	found := make(map[string]bool)
+	for _, record := range records {
+		info := &struct {
+			PubKey string `json:"pubkey"`
+		}{}
+		if err := json.Unmarshal(record, info); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to parse slashing protection record")
+		}
+		pubKey := strings.ToLower(info.PubKey)
+		if !strings.HasPrefix(pubKey, "0x") {
+			pubKey = "0x" + pubKey
+		}
+		if wanted[pubKey] {
+			filtered = append(filtered, record)
+			found[pubKey] = true
+		}
+	}
+
+	rawFiltered, err := json.Marshal(filtered)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to generate slashing protection records")
+	}
+	interchange["data"] = rawFiltered
+	res, err := json.MarshalIndent(interchange, "", "  ")
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to generate slashing protection data")
+	}
+	return res, len(found), nil
+}
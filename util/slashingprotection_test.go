@@ -0,0 +1,61 @@
+// Copyright © 2020 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestFilterSlashingProtection(t *testing.T) {
+	key1 := bytes.Repeat([]byte{0x01}, 48)
+	key2 := bytes.Repeat([]byte{0x02}, 48)
+	key3 := bytes.Repeat([]byte{0x03}, 48)
+	// key1 has two records, one with an upper case public key; key3 has none.
+	data := []byte(`{
+  "metadata": {"interchange_format_version": "5", "genesis_validators_root": "0x04700007fabc8282644aed6d1c7c9e21d38a03a0c4ba193f3afe428824b3a673"},
+  "data": [
+    {"pubkey": "0x010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101", "signed_blocks": [{"slot": "1"}]},
+    {"pubkey": "0x0202020202020202020202020202020202020202020202020202020202020202020202020202020202020202020202AA", "signed_blocks": []},
+    {"pubkey": "0X010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101", "signed_blocks": [{"slot": "2"}]},
+    {"pubkey": "0x020202020202020202020202020202020202020202020202020202020202020202020202020202020202020202020202", "signed_blocks": []}
+  ]
+}`)
+
+	filtered, keys, err := FilterSlashingProtection(data, [][]byte{key1, key2, key3})
+	if err != nil {
+		t.Fatalf("failed to filter: %v", err)
+	}
+	if keys != 2 {
+		t.Fatalf("expected records for 2 keys, got %d", keys)
+	}
+	res := &struct {
+		Metadata map[string]string `json:"metadata"`
+		Data     []json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(filtered, res); err != nil {
+		t.Fatalf("failed to parse filtered data: %v", err)
+	}
+	if len(res.Data) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(res.Data))
+	}
+	if res.Metadata["interchange_format_version"] != "5" {
+		t.Fatal("metadata not retained")
+	}
+
+	if _, _, err := FilterSlashingProtection([]byte(`{"data": []}`), [][]byte{key1}); err == nil {
+		t.Fatal("accepted data without metadata")
+	}
+}